@@ -0,0 +1,124 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ActivityItem is one entry in a user's activity timeline
+type ActivityItem struct {
+	Type    string      `json:"type"`
+	Created time.Time   `json:"created"`
+	Data    interface{} `json:"data"`
+	post    Post
+}
+
+// Get a user's activity, newest first. Drafts and scheduled posts are only
+// included when users look at their own timeline.
+func getUserActivity(c *gin.Context) {
+	var query Pagination
+	if !bindQuery(c, &query) {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+		return
+	}
+	if _, ok := findUserByID(id); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+		return
+	}
+	currentID, _ := currentUserID(c)
+	self := currentID == id
+
+	timeline := []ActivityItem{}
+	postsMu.RLock()
+	for _, post := range posts {
+		if post.UserID != id || post.DeletedAt != nil {
+			continue
+		}
+		if post.Status != postStatusPublished && !self {
+			continue
+		}
+		timeline = append(timeline, ActivityItem{Type: "post", Created: post.Created, Data: post, post: post})
+	}
+	postsMu.RUnlock()
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return newerPost(timeline[i].post, timeline[j].post)
+	})
+	start, end := paginate(c, len(timeline), query.Page, query.Limit)
+	c.JSON(http.StatusOK, timeline[start:end])
+}
+
+// UserStats summarises a user's published posts
+type UserStats struct {
+	UserID       int     `json:"user_id"`
+	Posts        int     `json:"posts"`
+	Likes        int     `json:"likes"`
+	Views        int     `json:"views"`
+	LikesPerPost float64 `json:"likes_per_post"`
+}
+
+// Get a user's post statistics; drafts, scheduled and deleted posts don't count
+func getUserStats(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+		return
+	}
+	if _, ok := findUserByID(id); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+		return
+	}
+	stats := UserStats{UserID: id}
+	postsMu.RLock()
+	for _, post := range posts {
+		if post.UserID == id && post.Status == postStatusPublished && post.DeletedAt == nil {
+			stats.Posts++
+			stats.Likes += post.Likes
+			stats.Views += post.Views
+		}
+	}
+	postsMu.RUnlock()
+	if stats.Posts > 0 {
+		stats.LikesPerPost = float64(stats.Likes) / float64(stats.Posts)
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// Count a user's live posts by status. The user themselves and admins see
+// every status; other callers only see the published count.
+func getUserPostCounts(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+		return
+	}
+	if _, ok := findUserByID(id); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+		return
+	}
+	callerID, ok := currentUserID(c)
+	counts := map[string]int{postStatusPublished: 0}
+	if (ok && callerID == id) || isAdmin(c) {
+		counts[postStatusDraft] = 0
+		counts[postStatusScheduled] = 0
+	}
+	postsMu.RLock()
+	for _, post := range posts {
+		if post.UserID != id || post.DeletedAt != nil {
+			continue
+		}
+		if _, shown := counts[post.Status]; shown {
+			counts[post.Status]++
+		}
+	}
+	postsMu.RUnlock()
+	c.JSON(http.StatusOK, counts)
+}
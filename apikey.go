@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKey lets a user authenticate with "Authorization: Bearer <key>" instead
+// of their password. Only a hash of the key is kept; the key itself is shown
+// once, when it is created.
+type APIKey struct {
+	ID       int        `json:"id"`
+	UserID   int        `json:"user_id"`
+	Name     string     `json:"name"`
+	Prefix   string     `json:"prefix"`
+	Hash     string     `json:"-"`
+	Created  time.Time  `json:"created"`
+	LastUsed *time.Time `json:"last_used,omitempty"`
+}
+
+// Prefix of every generated key, so leaked keys are easy to recognise
+const apiKeyPrefix = "gk_"
+
+var apiKeys = []APIKey{}
+var apiKeyIDSeq atomic.Int64
+var apiKeysMu sync.RWMutex
+
+// Helper function to hash a key the way it is stored
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Look up the active user owning an API key, recording when the key was used
+func authenticateAPIKey(key string) (User, bool) {
+	hash := hashAPIKey(key)
+	apiKeysMu.Lock()
+	userID := 0
+	for i, apiKey := range apiKeys {
+		if apiKey.Hash == hash {
+			now := time.Now()
+			apiKeys[i].LastUsed = &now
+			userID = apiKey.UserID
+			break
+		}
+	}
+	apiKeysMu.Unlock()
+	if userID == 0 {
+		return User{}, false
+	}
+	return findUserByID(userID)
+}
+
+// Get the API key from a bearer Authorization header, if the request has one
+func bearerAPIKey(c *gin.Context) (string, bool) {
+	key, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	return key, ok && key != ""
+}
+
+// Remove all API keys of a deleted user
+func removeAPIKeys(userID int) {
+	apiKeysMu.Lock()
+	defer apiKeysMu.Unlock()
+	kept := apiKeys[:0]
+	for _, apiKey := range apiKeys {
+		if apiKey.UserID != userID {
+			kept = append(kept, apiKey)
+		}
+	}
+	apiKeys = kept
+}
+
+// List the authenticated user's API keys, without the keys themselves
+func getAPIKeys(c *gin.Context) {
+	user := mustAuth(c)
+	if user == nil {
+		return
+	}
+	result := []APIKey{}
+	apiKeysMu.RLock()
+	for _, apiKey := range apiKeys {
+		if apiKey.UserID == user.ID {
+			result = append(result, apiKey)
+		}
+	}
+	apiKeysMu.RUnlock()
+	c.JSON(http.StatusOK, result)
+}
+
+// Create an API key for the authenticated user. The response is the only
+// time the key is returned; existing keys keep working.
+func createAPIKey(c *gin.Context) {
+	var body struct {
+		Name string `json:"name" binding:"required,max=100"`
+	}
+	if err := bindJSON(c, &body); err != nil {
+		handleBindError(c, err)
+		return
+	}
+	user := mustAuth(c)
+	if user == nil {
+		return
+	}
+	apiKeysMu.Lock()
+	defer apiKeysMu.Unlock()
+	count := 0
+	for _, apiKey := range apiKeys {
+		if apiKey.UserID == user.ID {
+			count++
+		}
+	}
+	if count >= maxAPIKeysPerUser {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("At most %d API keys are allowed", maxAPIKeysPerUser)})
+		return
+	}
+	key := apiKeyPrefix + randomHex(24)
+	apiKey := APIKey{
+		ID:      nextID(&apiKeyIDSeq),
+		UserID:  user.ID,
+		Name:    body.Name,
+		Prefix:  key[:len(apiKeyPrefix)+8],
+		Hash:    hashAPIKey(key),
+		Created: time.Now(),
+	}
+	apiKeys = append(apiKeys, apiKey)
+	recordAudit(c, "api_key.created", "api_key", apiKey.ID, apiKey.Name)
+	c.JSON(http.StatusCreated, struct {
+		APIKey
+		Key string `json:"key"`
+	}{apiKey, key})
+}
+
+// Revoke one of the authenticated user's API keys
+func deleteAPIKey(c *gin.Context) {
+	user := mustAuth(c)
+	if user == nil {
+		return
+	}
+	id := c.Param("id")
+	apiKeysMu.Lock()
+	defer apiKeysMu.Unlock()
+	for i, apiKey := range apiKeys {
+		if strconv.Itoa(apiKey.ID) == id && apiKey.UserID == user.ID {
+			apiKeys = append(apiKeys[:i], apiKeys[i+1:]...)
+			recordAudit(c, "api_key.revoked", "api_key", apiKey.ID, apiKey.Name)
+			c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"message": "API key not found"})
+}
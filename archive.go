@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ArchiveMonth is the number of published posts in one calendar month (UTC)
+type ArchiveMonth struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Count int `json:"count"`
+}
+
+// Get published post counts per month, newest month first
+func getPostArchive(c *gin.Context) {
+	var query struct {
+		Year int `form:"year"`
+	}
+	if !bindQuery(c, &query) {
+		return
+	}
+	year := query.Year
+
+	counts := map[[2]int]int{}
+	postsMu.RLock()
+	for _, post := range posts {
+		if post.Status != postStatusPublished || post.DeletedAt != nil {
+			continue
+		}
+		created := post.Created.UTC()
+		if year != 0 && created.Year() != year {
+			continue
+		}
+		counts[[2]int{created.Year(), int(created.Month())}]++
+	}
+	postsMu.RUnlock()
+
+	archive := make([]ArchiveMonth, 0, len(counts))
+	for key, count := range counts {
+		archive = append(archive, ArchiveMonth{Year: key[0], Month: key[1], Count: count})
+	}
+	sort.Slice(archive, func(i, j int) bool {
+		if archive[i].Year != archive[j].Year {
+			return archive[i].Year > archive[j].Year
+		}
+		return archive[i].Month > archive[j].Month
+	})
+	c.JSON(http.StatusOK, archive)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditEntry records an administrative action taken by a user
+type AuditEntry struct {
+	ID         int       `json:"id"`
+	ActorID    int       `json:"actor_id"`
+	Action     string    `json:"action"`
+	Resource   string    `json:"resource"`
+	ResourceID int       `json:"resource_id"`
+	Details    string    `json:"details,omitempty"`
+	Created    time.Time `json:"created"`
+}
+
+var auditLog = []AuditEntry{}
+var auditIDSeq atomic.Int64
+var auditMu sync.RWMutex
+
+// Record an action performed by the authenticated user
+func recordAudit(c *gin.Context, action, resource string, resourceID int, details string) {
+	actorID, _ := currentUserID(c)
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	auditLog = append(auditLog, AuditEntry{
+		ID:         nextID(&auditIDSeq),
+		ActorID:    actorID,
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Details:    details,
+		Created:    time.Now(),
+	})
+}
+
+// Get the most recent audit entries across the system, newest first,
+// optionally filtered by action and resource type; either may be repeated to
+// match any of the values given
+func getAdminActivity(c *gin.Context) {
+	var query struct {
+		Pagination
+		Actions   []string `form:"action"`
+		Resources []string `form:"resource"`
+	}
+	if !bindQuery(c, &query) {
+		return
+	}
+	entries := []AuditEntry{}
+	auditMu.RLock()
+	for i := len(auditLog) - 1; i >= 0; i-- {
+		entry := auditLog[i]
+		if len(query.Actions) > 0 && !slices.Contains(query.Actions, entry.Action) {
+			continue
+		}
+		if len(query.Resources) > 0 && !slices.Contains(query.Resources, entry.Resource) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	auditMu.RUnlock()
+	start, end := paginate(c, len(entries), query.Page, query.Limit)
+	c.JSON(http.StatusOK, entries[start:end])
+}
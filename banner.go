@@ -0,0 +1,55 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Log the effective configuration once at startup, before the server starts
+// listening, so a misconfigured deployment shows up in its first log lines.
+// Secrets are never logged.
+func logStartup(srv *http.Server) {
+	logger.Info("server starting",
+		slog.String("addr", srv.Addr),
+		slog.String("mode", gin.Mode()),
+		slog.String("version", version),
+		slog.String("commit", commit),
+		slog.Group("timeouts",
+			slog.Duration("read_header", srv.ReadHeaderTimeout),
+			slog.Duration("read", srv.ReadTimeout),
+			slog.Duration("write", srv.WriteTimeout),
+			slog.Duration("idle", srv.IdleTimeout),
+			slog.Duration("public", publicTimeout),
+			slog.Duration("auth", authTimeout),
+			slog.Duration("shutdown", shutdownTimeout),
+			slog.Duration("drain_grace", drainGracePeriod),
+		),
+		slog.Group("limits",
+			slog.Int("api_quota", apiQuota),
+			slog.Duration("api_quota_window", apiQuotaWindow),
+			slog.Int64("max_body_bytes", maxBodyBytes),
+			slog.Int("max_header_bytes", srv.MaxHeaderBytes),
+			slog.Int("max_posts_per_user", maxPostsPerUser),
+			slog.Int("default_page_limit", defaultLimit),
+			slog.Int("max_page_limit", maxLimit),
+		),
+		slog.Group("security",
+			slog.Any("cors_allowed_origins", authCORS.AllowOrigins),
+			slog.Int("admin_ip_allow", len(adminIPAllow)),
+			slog.Int("admin_ip_deny", len(adminIPDeny)),
+			slog.Bool("require_if_match", requireIfMatch),
+			slog.Bool("strict_json", strictJSON),
+			slog.String("default_user_role", defaultUserRole),
+			slog.Bool("first_user_admin", firstUserAdmin),
+			slog.Bool("read_only", readOnly.Load()),
+		),
+		slog.Group("logging",
+			slog.String("output", logOutput),
+			slog.Float64("sample_rate", logSampleRate),
+			slog.Duration("slow_request_threshold", slowRequestThreshold),
+		),
+		slog.Any("features", features),
+	)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Response writer that fills in Cache-Control just before the headers go out,
+// once the response status is known
+type cacheControlWriter struct {
+	gin.ResponseWriter
+	c *gin.Context
+}
+
+func (w *cacheControlWriter) setHeader() {
+	if !w.Written() && w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", cacheControl(w.c, w.Status()))
+	}
+}
+
+func (w *cacheControlWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *cacheControlWriter) Write(b []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheControlWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Middleware that sets Cache-Control on every response a handler hasn't set
+// it on. Successful GETs of the routes in routeCacheMaxAge may be cached:
+// publicly for anonymous requests, and only by the client, revalidating with
+// the ETag, for authenticated ones. Everything else is marked no-store.
+func CacheControlMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer = &cacheControlWriter{ResponseWriter: c.Writer, c: c}
+		c.Next()
+		c.Writer.WriteHeaderNow()
+	}
+}
+
+// Helper function to pick the Cache-Control value for a response
+func cacheControl(c *gin.Context, status int) string {
+	maxAge, ok := routeCacheMaxAge[c.FullPath()]
+	cacheable := ok && maxAge > 0 &&
+		(c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) &&
+		(status == http.StatusOK || status == http.StatusNotModified)
+	switch {
+	case !cacheable:
+		return "no-store"
+	case c.GetHeader("Authorization") != "":
+		return "private, no-cache"
+	default:
+		return "public, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+	}
+}
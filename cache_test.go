@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestCacheControl(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	user := createTestUser(t, r, "jane", "secret123")
+	post := createTestPost(t, r, `{"title":"t","content":"c"}`, dummyUser.Username, dummyUser.Password)
+
+	tests := []struct {
+		name, method, path string
+		auth               []string
+		want               string
+	}{
+		{"anonymous user list", http.MethodGet, "/users", nil, "no-store"},
+		{"anonymous user profile", http.MethodGet, "/users/" + strconv.Itoa(user.ID), nil, "no-store"},
+		{"authenticated user list", http.MethodGet, "/users", []string{"jane", "secret123"}, "no-store"},
+		{"anonymous public document", http.MethodGet, "/openapi.json", nil, "public, max-age=60"},
+		{"authenticated post", http.MethodGet, "/posts/" + strconv.Itoa(post.ID), []string{"jane", "secret123"}, "private, no-cache"},
+		{"mutation", http.MethodPost, "/posts", []string{"jane", "secret123"}, "no-store"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(t, r, tt.method, tt.path, "", tt.auth...)
+			if got := w.Header().Get("Cache-Control"); got != tt.want {
+				t.Errorf("Cache-Control = %q (status %d), want %q", got, w.Code, tt.want)
+			}
+		})
+	}
+}
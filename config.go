@@ -0,0 +1,304 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Read a string setting from the environment, falling back to def
+func getEnv(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+// Split a comma separated setting into its trimmed, non-empty entries
+func splitList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Settings that failed to parse, as logger attributes. They are read while
+// package variables initialize, before the logger they configure exists, so
+// the warnings are only logged once it does.
+var invalidSettings [][]any
+
+// Helper function to remember a setting that failed to parse and the default
+// used instead
+func warnInvalidSetting(key, value string, def any) {
+	invalidSettings = append(invalidSettings, []any{"key", key, "value", value, "default", def})
+}
+
+// Read an integer setting from the environment, falling back to def
+func getEnvInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		warnInvalidSetting(key, v, def)
+		return def
+	}
+	return n
+}
+
+// Read a float setting from the environment, falling back to def
+func getEnvFloat(key string, def float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		warnInvalidSetting(key, v, def)
+		return def
+	}
+	return f
+}
+
+// Read a boolean setting from the environment, falling back to def
+func getEnvBool(key string, def bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		warnInvalidSetting(key, v, def)
+		return def
+	}
+	return b
+}
+
+// Read a duration setting (e.g. "1s", "500ms") from the environment, falling back to def
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		warnInvalidSetting(key, v, def)
+		return def
+	}
+	return d
+}
+
+// Maximum number of body bytes logged by the debug body logger
+var debugBodyLogLimit = getEnvInt("DEBUG_BODY_LOG_LIMIT", 1024)
+
+// Maximum number of posts a non-admin user may own
+var maxPostsPerUser = getEnvInt("MAX_POSTS_PER_USER", 100)
+
+// Weights for the relevance score used by the ranked feed
+var (
+	feedLikeWeight = getEnvFloat("FEED_LIKE_WEIGHT", 1)
+	feedViewWeight = getEnvFloat("FEED_VIEW_WEIGHT", 0.1)
+	feedGravity    = getEnvFloat("FEED_GRAVITY", 1.8)
+)
+
+// Reject updates that don't carry an If-Match header
+var requireIfMatch = getEnvBool("REQUIRE_IF_MATCH", false)
+
+// Requests slower than this are logged with a warning
+var slowRequestThreshold = getEnvDuration("SLOW_REQUEST_THRESHOLD", time.Second)
+
+// Validate request bodies against the JSON schemas in schemaDir
+var (
+	schemaValidation = getEnvBool("SCHEMA_VALIDATION", false)
+	schemaDir        = getEnv("SCHEMA_DIR", "schemas")
+)
+
+// CIDR allow and deny lists for the admin routes
+var (
+	adminIPAllow = parseIPRanges(os.Getenv("ADMIN_IP_ALLOW"))
+	adminIPDeny  = parseIPRanges(os.Getenv("ADMIN_IP_DENY"))
+)
+
+// Page size used by list endpoints when no limit is given, and the largest allowed
+var (
+	defaultLimit = getEnvInt("DEFAULT_PAGE_LIMIT", 20)
+	maxLimit     = getEnvInt("MAX_PAGE_LIMIT", 100)
+)
+
+// Order of list endpoints when the request has no ?sort=: posts newest first,
+// users by username
+var (
+	postsDefaultSort = getEnv("POSTS_DEFAULT_SORT", "-created")
+	usersDefaultSort = getEnv("USERS_DEFAULT_SORT", "username")
+)
+
+// Reject JSON bodies containing fields the endpoint doesn't know about
+var strictJSON = getEnvBool("STRICT_JSON", true)
+
+// Decode JSON numbers landing in interface{} values (maps, logged bodies) as
+// json.Number instead of float64, so large integer IDs keep every digit
+var jsonUseNumber = getEnvBool("JSON_USE_NUMBER", true)
+
+// Settings for the RSS and Atom feeds
+var (
+	siteTitle         = getEnv("SITE_TITLE", "GinGo")
+	siteURL           = getEnv("SITE_URL", "http://localhost:8080")
+	feedItemCount     = getEnvInt("FEED_ITEM_COUNT", 20)
+	feedExcerptLength = getEnvInt("FEED_EXCERPT_LENGTH", 200)
+)
+
+// Exit at startup when the HTML templates fail to load
+var strictTemplates = getEnvBool("STRICT_TEMPLATES", false)
+
+// Send the X-Response-Time header with every response
+var responseTimeHeader = getEnvBool("RESPONSE_TIME_HEADER", true)
+
+// Largest request body accepted by default, and per-route overrides
+var (
+	maxBodyBytes    = int64(getEnvInt("MAX_BODY_BYTES", 1<<20))
+	routeBodyLimits = map[string]int64{
+		"/posts/import": maxImportBytes,
+	}
+)
+
+// How long successful GETs of each route may be cached, keyed by route path.
+// Routes that aren't listed are never cached. User routes are left out on
+// purpose: what they show depends on who is asking.
+var (
+	cacheMaxAge      = getEnvDuration("CACHE_MAX_AGE", time.Minute)
+	feedCacheMaxAge  = getEnvDuration("FEED_CACHE_MAX_AGE", 5*time.Minute)
+	routeCacheMaxAge = map[string]time.Duration{
+		"/posts":        cacheMaxAge,
+		"/posts/:id":    cacheMaxAge,
+		"/feed.xml":     feedCacheMaxAge,
+		"/atom.xml":     feedCacheMaxAge,
+		"/openapi.json": cacheMaxAge,
+	}
+)
+
+// Largest post import file, and the most posts one import may contain
+var (
+	maxImportBytes   = int64(getEnvInt("MAX_IMPORT_BYTES", 10<<20))
+	maxImportRecords = getEnvInt("MAX_IMPORT_RECORDS", 1000)
+)
+
+// Webhook delivery timeout per attempt and number of attempts
+var (
+	webhookTimeout     = getEnvDuration("WEBHOOK_TIMEOUT", 5*time.Second)
+	webhookMaxAttempts = getEnvInt("WEBHOOK_MAX_ATTEMPTS", 3)
+)
+
+// HTTP server limits protecting against slow clients. Defaults: 5s to send
+// headers, 15s to send the whole request, 30s to write the response, 60s
+// for idle keep-alive connections, and 1 MiB of headers. WriteTimeout caps
+// every response, so long-lived streaming endpoints would need their own
+// server or per-handler deadlines; there are none in this service yet.
+var (
+	readHeaderTimeout = getEnvDuration("READ_HEADER_TIMEOUT", 5*time.Second)
+	readTimeout       = getEnvDuration("READ_TIMEOUT", 15*time.Second)
+	writeTimeout      = getEnvDuration("WRITE_TIMEOUT", 30*time.Second)
+	idleTimeout       = getEnvDuration("IDLE_TIMEOUT", 60*time.Second)
+	maxHeaderBytes    = getEnvInt("MAX_HEADER_BYTES", 1<<20)
+)
+
+// Maximum number of IDs accepted by batch lookups
+var maxBatchIDs = getEnvInt("MAX_BATCH_IDS", 100)
+
+// Length of the excerpt shown instead of the content in post lists, in characters
+var postExcerptLength = getEnvInt("POST_EXCERPT_LENGTH", 200)
+
+// A post with the same title and content as one its author created this
+// recently is rejected as a double submission
+var duplicatePostWindow = getEnvDuration("DUPLICATE_POST_WINDOW", 5*time.Minute)
+
+// Strip dangerous HTML from post content before storing it. Off by default
+// so content is kept exactly as written.
+var sanitizeContent = getEnvBool("SANITIZE_POST_CONTENT", false)
+
+// Most requests to the expensive routes (search, preview, import) handled at
+// once, and whether requests over the limit queue instead of getting a 503;
+// 0 turns the limit off
+var (
+	maxConcurrentExpensive = getEnvInt("MAX_CONCURRENT_EXPENSIVE", 4)
+	expensiveQueue         = getEnvBool("EXPENSIVE_QUEUE", false)
+)
+
+// Role given to new users, and whether the first user created on an empty
+// store becomes an admin so a fresh system always has one
+var (
+	defaultUserRole = getEnv("DEFAULT_USER_ROLE", roleUser)
+	firstUserAdmin  = getEnvBool("FIRST_USER_ADMIN", true)
+)
+
+// Realm named in the WWW-Authenticate challenge of 401 responses
+var authRealm = getEnv("AUTH_REALM", siteTitle)
+
+// Most API keys a user may hold at the same time
+var maxAPIKeysPerUser = getEnvInt("MAX_API_KEYS_PER_USER", 5)
+
+// Most posts that can be pinned at the same time
+var maxPinnedPosts = getEnvInt("MAX_PINNED_POSTS", 5)
+
+// Posts listed as similar by content by default, and the lowest cosine
+// similarity (0 to 1) a post needs to be listed
+var (
+	similarPostsLimit = getEnvInt("SIMILAR_POSTS_LIMIT", 5)
+	similarThreshold  = getEnvFloat("SIMILAR_POSTS_THRESHOLD", 0.1)
+)
+
+// Longest post content accepted, in characters
+var maxPostContentLength = getEnvInt("MAX_POST_CONTENT_LENGTH", 20000)
+
+// Most tags a post may carry, and the longest tag allowed, in characters
+var (
+	maxTagsPerPost = getEnvInt("MAX_TAGS_PER_POST", 10)
+	maxTagLength   = getEnvInt("MAX_TAG_LENGTH", 30)
+)
+
+// Shortest and longest usernames accepted, in characters
+var (
+	minUsernameLength = getEnvInt("MIN_USERNAME_LENGTH", 3)
+	maxUsernameLength = getEnvInt("MAX_USERNAME_LENGTH", 30)
+)
+
+// Reading speed used to estimate reading time
+var readingWordsPerMinute = getEnvInt("READING_WORDS_PER_MINUTE", 200)
+
+// Fraction of successful requests written to the request log, from 0 to 1.
+// Error responses and slow requests are always logged.
+var logSampleRate = getEnvFloat("LOG_SAMPLE_RATE", 1)
+
+// Log destination: "stdout", "file" (rotated by size) or "syslog", and the
+// rotation limits for the file sink
+var (
+	logOutput     = getEnv("LOG_OUTPUT", "stdout")
+	logFile       = getEnv("LOG_FILE", "gingo.log")
+	logMaxSizeMB  = getEnvInt("LOG_MAX_SIZE_MB", 100)
+	logMaxBackups = getEnvInt("LOG_MAX_BACKUPS", 5)
+	logMaxAgeDays = getEnvInt("LOG_MAX_AGE_DAYS", 28)
+)
+
+// Requests each user (or anonymous IP) may make per quota window; 0 turns quotas off
+var (
+	apiQuota       = getEnvInt("API_QUOTA", 100000)
+	apiQuotaWindow = getEnvDuration("API_QUOTA_WINDOW", 30*24*time.Hour)
+)
+
+// How long a drained server keeps answering (with failing health checks) before
+// it stops accepting connections, and how long in-flight requests get to finish
+var (
+	drainGracePeriod = getEnvDuration("DRAIN_GRACE_PERIOD", 30*time.Second)
+	shutdownTimeout  = getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second)
+)
+
+// Request deadlines per route group: public reads get 2s, authenticated
+// routes (posts, admin) 5s; 0 disables the group's timeout
+var (
+	publicTimeout = getEnvDuration("PUBLIC_TIMEOUT", 2*time.Second)
+	authTimeout   = getEnvDuration("AUTH_TIMEOUT", 5*time.Second)
+)
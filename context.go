@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context key holding the user authenticated by AuthMiddleware
+const authUserKey = "auth_user"
+
+// Store the authenticated user in the request context
+func setCurrentUser(c *gin.Context, user User) {
+	c.Set(authUserKey, &user)
+}
+
+// Get the authenticated user, if the request has one
+func currentUser(c *gin.Context) (*User, bool) {
+	user, ok := c.Get(authUserKey)
+	if !ok {
+		return nil, false
+	}
+	u, ok := user.(*User)
+	return u, ok
+}
+
+// Get the ID of the authenticated user, if the request has one
+func currentUserID(c *gin.Context) (int, bool) {
+	if user, ok := currentUser(c); ok {
+		return user.ID, true
+	}
+	return 0, false
+}
+
+// Check whether the authenticated user is an admin
+func isAdmin(c *gin.Context) bool {
+	user, ok := currentUser(c)
+	return ok && user.Role == roleAdmin
+}
+
+// Abort with 401 for missing or invalid credentials, telling the client
+// which authentication schemes are accepted
+func abortUnauthenticated(c *gin.Context) {
+	c.Header("WWW-Authenticate", fmt.Sprintf(`Basic realm=%q, charset="UTF-8", Bearer realm=%q`, authRealm, authRealm))
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "unauthorized", "code": "unauthenticated", "message": "authentication required"})
+}
+
+// Abort with 403 for a caller who authenticated but may not do this; code
+// tells clients why and message says it for people
+func abortForbidden(c *gin.Context, code, message string) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "forbidden", "code": code, "message": message})
+}
+
+// Get the authenticated user, or abort with 401 and return nil when there is none
+func mustAuth(c *gin.Context) *User {
+	user, ok := currentUser(c)
+	if !ok {
+		abortUnauthenticated(c)
+		return nil
+	}
+	return user
+}
+
+// Helper function to check whether the authenticated user may manage a post:
+// its author and admins can
+func canManagePost(c *gin.Context, post Post) bool {
+	userID, ok := currentUserID(c)
+	return (ok && post.UserID == userID) || isAdmin(c)
+}
+
+// Helper function to check whether the authenticated user may manage a user
+// account: its owner and admins can
+func canManageUser(c *gin.Context, userID int) bool {
+	id, ok := currentUserID(c)
+	return (ok && id == userID) || isAdmin(c)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSOptions is the cross-origin policy of one route group
+type CORSOptions struct {
+	AllowOrigins     []string // "*" allows any origin
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// Response headers scripts may read: pagination, versions, tracing and quotas
+var exposedHeaders = []string{
+	"Link", "X-Total-Count", "ETag", "Location", "X-Request-ID", "X-Response-Time",
+	"Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+}
+
+// Public endpoints: any origin, no credentials. Only reads and registration
+// live here, so PUT and DELETE aren't allowed.
+var publicCORS = CORSOptions{
+	AllowOrigins:  []string{"*"},
+	AllowMethods:  []string{"GET", "POST"},
+	AllowHeaders:  []string{"Content-Type", "If-None-Match"},
+	ExposeHeaders: exposedHeaders,
+	MaxAge:        12 * time.Hour,
+}
+
+// Authenticated endpoints: only the configured origins, with credentials
+var authCORS = CORSOptions{
+	AllowOrigins:     splitList(getEnv("CORS_ALLOWED_ORIGINS", "")),
+	AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+	AllowHeaders:     []string{"Authorization", "Content-Type", "If-Match", "If-None-Match"},
+	ExposeHeaders:    exposedHeaders,
+	AllowCredentials: true,
+	MaxAge:           12 * time.Hour,
+}
+
+// HTML pages rendered from the templates aren't called cross-origin, so instead
+// of CORS they get a content security policy suited to browser rendering. It
+// allows the page's own assets and Google Fonts, and only same-origin framing.
+var pageCSP = getEnv("PAGE_CSP", "default-src 'self'; "+
+	"style-src 'self' https://fonts.googleapis.com; font-src 'self' https://fonts.gstatic.com; "+
+	"img-src 'self' data:; frame-ancestors 'self'")
+
+// Middleware for the HTML page group, setting its content security policy
+func PageSecurityMiddleware(csp string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Security-Policy", csp)
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Next()
+	}
+}
+
+// Middleware that applies a CORS policy and answers preflight requests
+func CORSMiddleware(opts CORSOptions) gin.HandlerFunc {
+	anyOrigin := false
+	allowed := map[string]bool{}
+	for _, origin := range opts.AllowOrigins {
+		if origin == "*" {
+			anyOrigin = true
+		}
+		allowed[origin] = true
+	}
+	methods := strings.Join(opts.AllowMethods, ", ")
+	headers := strings.Join(opts.AllowHeaders, ", ")
+	exposed := strings.Join(opts.ExposeHeaders, ", ")
+	maxAge := strconv.Itoa(int(opts.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		c.Header("Vary", "Origin")
+		if !anyOrigin && !allowed[origin] {
+			if c.Request.Method == http.MethodOptions {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+		if anyOrigin && !opts.AllowCredentials {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if opts.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+		if c.Request.Method == http.MethodOptions {
+			c.Header("Access-Control-Allow-Methods", methods)
+			c.Header("Access-Control-Allow-Headers", headers)
+			c.Header("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		if exposed != "" {
+			c.Header("Access-Control-Expose-Headers", exposed)
+		}
+		c.Next()
+	}
+}
+
+// Register OPTIONS routes so preflight requests reach the group's CORS middleware
+func preflight(group *gin.RouterGroup, paths ...string) {
+	for _, path := range paths {
+		group.OPTIONS(path, func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+	}
+}
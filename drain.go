@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Draining mode fails the health check so load balancers stop routing here,
+// then shuts the server down once drainGracePeriod has passed
+var (
+	draining  atomic.Bool
+	drainOnce sync.Once
+	drainCh   = make(chan struct{})
+)
+
+// Put the server into draining mode. Calling it again while draining is a no-op.
+func startDrain(c *gin.Context) {
+	drainOnce.Do(func() {
+		draining.Store(true)
+		close(drainCh)
+		recordAudit(c, "server.drain", "server", 0, "grace period "+drainGracePeriod.String())
+		logger.Warn("draining", "grace_period", drainGracePeriod, "request_id", c.GetString("request_id"))
+	})
+	c.JSON(http.StatusAccepted, gin.H{"status": "draining", "grace_period": drainGracePeriod.String()})
+}
+
+// Wait for a drain, give load balancers the grace period to notice, then shut
+// srv down, letting in-flight requests finish within shutdownTimeout
+func shutdownOnDrain(srv *http.Server) {
+	<-drainCh
+	time.Sleep(drainGracePeriod)
+	logger.Info("drain grace period over, shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("shutdown did not complete", "error", err)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Compute a strong ETag from the JSON representation of a resource
+func computeETag(resource interface{}) string {
+	data, _ := json.Marshal(resource)
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// The fields a user's ETag is computed from: every public field, email
+// included, so the ETag is the same for every caller, and never the
+// password, so changing it doesn't show in the ETag
+func userVersion(user User) PublicUser {
+	return PublicUser{
+		ID:       user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		Role:     user.Role,
+		Active:   user.Active,
+		Created:  user.Created,
+	}
+}
+
+// Check the If-Match precondition against the current resource version.
+// Writes a 412 or 428 response and returns false when the update must not proceed.
+func checkIfMatch(c *gin.Context, resource interface{}) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		if requireIfMatch {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+			return false
+		}
+		return true
+	}
+	current := computeETag(resource)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Resource has been modified"})
+	return false
+}
+
+// Answer 304 when the If-None-Match header already names the current ETag
+func checkIfNoneMatch(c *gin.Context, etag string) bool {
+	for _, tag := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
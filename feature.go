@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Feature flags, seeded from FEATURE_FLAGS ("name=true,other=false") on top
+// of the defaults below and changeable at runtime through the admin API.
+// Besides whole features, flags switch single endpoints on and off, e.g.
+// "registration=false" closes POST /users.
+var (
+	features = map[string]bool{
+		"feeds":        true,
+		"registration": true,
+		"post_import":  true,
+	}
+	featuresMu sync.RWMutex
+)
+
+func init() {
+	for _, entry := range splitList(getEnv("FEATURE_FLAGS", "")) {
+		name, value, _ := strings.Cut(entry, "=")
+		enabled, err := strconv.ParseBool(value)
+		if value == "" {
+			enabled, err = true, nil
+		}
+		if err == nil {
+			features[strings.TrimSpace(name)] = enabled
+		}
+	}
+}
+
+// Check whether a feature is switched on; unknown features are off
+func isFeatureEnabled(name string) bool {
+	featuresMu.RLock()
+	defer featuresMu.RUnlock()
+	return features[name]
+}
+
+// Middleware that hides routes behind a feature flag, answering 404 while it's off
+func FeatureMiddleware(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isFeatureEnabled(name) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "Not found"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Middleware that takes an endpoint out of service behind a feature flag,
+// answering 503 with the given message while it's off
+func EndpointMiddleware(name, message string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isFeatureEnabled(name) {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": message})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Get all feature flags
+func getFeatures(c *gin.Context) {
+	featuresMu.RLock()
+	result := make(map[string]bool, len(features))
+	for name, enabled := range features {
+		result[name] = enabled
+	}
+	featuresMu.RUnlock()
+	c.JSON(http.StatusOK, result)
+}
+
+// Switch a feature flag on or off
+func setFeature(c *gin.Context) {
+	var body struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+	if err := bindJSON(c, &body); err != nil {
+		handleBindError(c, err)
+		return
+	}
+	name := c.Param("name")
+	featuresMu.Lock()
+	features[name] = *body.Enabled
+	featuresMu.Unlock()
+	recordAudit(c, "feature.updated", "feature", 0, name+"="+strconv.FormatBool(*body.Enabled))
+	c.JSON(http.StatusOK, gin.H{"name": name, "enabled": *body.Enabled})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Score a post for the ranked feed using Hacker News style gravity decay
+func relevanceScore(post Post, now time.Time) float64 {
+	points := float64(post.Likes)*feedLikeWeight + float64(post.Views)*feedViewWeight
+	ageHours := now.Sub(post.Created).Hours()
+	if ageHours < 0 {
+		ageHours = 0
+	}
+	return (points + 1) / math.Pow(ageHours+2, feedGravity)
+}
+
+// Get published posts ranked by relevance
+func getFeed(c *gin.Context) {
+	var query Pagination
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	now := time.Now()
+	feed := []Post{}
+	postsMu.RLock()
+	for _, post := range posts {
+		if post.Status == postStatusPublished && post.DeletedAt == nil {
+			feed = append(feed, post)
+		}
+	}
+	postsMu.RUnlock()
+	sort.SliceStable(feed, func(i, j int) bool {
+		si, sj := relevanceScore(feed[i], now), relevanceScore(feed[j], now)
+		if si != sj {
+			return si > sj
+		}
+		return feed[i].ID > feed[j].ID
+	})
+	pinnedFirst(feed)
+
+	start, end := paginate(c, len(feed), query.Page, query.Limit)
+	c.JSON(http.StatusOK, feed[start:end])
+}
+
+// Get published posts by the users the authenticated user follows, newest
+// first. The user's own posts are left out unless include_self is set.
+func getFollowingFeed(c *gin.Context) {
+	var query struct {
+		Pagination
+		IncludeSelf bool `form:"include_self"`
+	}
+	if !bindQuery(c, &query) {
+		return
+	}
+	user := mustAuth(c)
+	if user == nil {
+		return
+	}
+	authors := map[int]bool{user.ID: query.IncludeSelf}
+	followsMu.RLock()
+	for _, follow := range follows {
+		if follow.FollowerID == user.ID {
+			authors[follow.FolloweeID] = true
+		}
+	}
+	followsMu.RUnlock()
+
+	feed := []Post{}
+	postsMu.RLock()
+	for _, post := range posts {
+		if authors[post.UserID] && post.Status == postStatusPublished && post.DeletedAt == nil {
+			feed = append(feed, post)
+		}
+	}
+	postsMu.RUnlock()
+	sort.SliceStable(feed, func(i, j int) bool {
+		return newerPost(feed[i], feed[j])
+	})
+	pinnedFirst(feed)
+
+	start, end := paginate(c, len(feed), query.Page, query.Limit)
+	c.JSON(http.StatusOK, feed[start:end])
+}
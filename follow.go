@@ -0,0 +1,182 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Follow model represents one user following another
+type Follow struct {
+	FollowerID int       `json:"follower_id"`
+	FolloweeID int       `json:"followee_id"`
+	Created    time.Time `json:"created"`
+}
+
+var follows = []Follow{}
+var followsMu sync.RWMutex
+
+// UserProfile is a public user along with their follow counts
+type UserProfile struct {
+	PublicUser
+	FollowersCount int `json:"followers_count"`
+	FollowingCount int `json:"following_count"`
+}
+
+// Helper function to count a user's followers and the users they follow
+func followCounts(userID int) (followers, following int) {
+	followsMu.RLock()
+	defer followsMu.RUnlock()
+	for _, follow := range follows {
+		if follow.FolloweeID == userID {
+			followers++
+		}
+		if follow.FollowerID == userID {
+			following++
+		}
+	}
+	return followers, following
+}
+
+// Helper function to drop every follow a deleted user was part of
+func removeFollows(userID int) {
+	followsMu.Lock()
+	defer followsMu.Unlock()
+	kept := follows[:0]
+	for _, follow := range follows {
+		if follow.FollowerID != userID && follow.FolloweeID != userID {
+			kept = append(kept, follow)
+		}
+	}
+	follows = kept
+}
+
+// Helper function to resolve the :id user of a follow route, answering 404 when unknown
+func followTarget(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err == nil {
+		if _, ok := findUserByID(id); ok {
+			return id, true
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+	return 0, false
+}
+
+// Get a user's public profile
+func getUserProfile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+		return
+	}
+	user, ok := findUserByID(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+		return
+	}
+	followers, following := followCounts(id)
+	// The ETag is the one PUT /users/:id checks If-Match against
+	c.Header("ETag", computeETag(userVersion(user)))
+	c.JSON(http.StatusOK, UserProfile{PublicUser: toPublicUser(c, user), FollowersCount: followers, FollowingCount: following})
+}
+
+// Follow a user as the authenticated user. Following someone twice is a no-op.
+func followUser(c *gin.Context) {
+	followeeID, ok := followTarget(c)
+	if !ok {
+		return
+	}
+	user := mustAuth(c)
+	if user == nil {
+		return
+	}
+	followerID := user.ID
+	if followerID == followeeID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot follow yourself"})
+		return
+	}
+	followsMu.Lock()
+	defer followsMu.Unlock()
+	for _, follow := range follows {
+		if follow.FollowerID == followerID && follow.FolloweeID == followeeID {
+			c.JSON(http.StatusOK, follow)
+			return
+		}
+	}
+	follow := Follow{FollowerID: followerID, FolloweeID: followeeID, Created: time.Now()}
+	follows = append(follows, follow)
+	notify(followeeID, notificationFollow, map[string]interface{}{
+		"follower_id": followerID,
+		"username":    user.Username,
+	})
+	c.JSON(http.StatusCreated, follow)
+}
+
+// Stop following a user. Unfollowing someone not followed is a no-op.
+func unfollowUser(c *gin.Context) {
+	followeeID, ok := followTarget(c)
+	if !ok {
+		return
+	}
+	user := mustAuth(c)
+	if user == nil {
+		return
+	}
+	followerID := user.ID
+	followsMu.Lock()
+	defer followsMu.Unlock()
+	for i, follow := range follows {
+		if follow.FollowerID == followerID && follow.FolloweeID == followeeID {
+			follows = append(follows[:i], follows[i+1:]...)
+			break
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Get the users following a user
+func getFollowers(c *gin.Context) {
+	listFollows(c, func(follow Follow, id int) (int, bool) {
+		return follow.FollowerID, follow.FolloweeID == id
+	})
+}
+
+// Get the users a user follows
+func getFollowing(c *gin.Context) {
+	listFollows(c, func(follow Follow, id int) (int, bool) {
+		return follow.FolloweeID, follow.FollowerID == id
+	})
+}
+
+// Helper function to list one side of a user's follows, oldest follow first.
+// pick returns the user to list for a follow and whether it involves the user.
+func listFollows(c *gin.Context, pick func(follow Follow, id int) (int, bool)) {
+	var query Pagination
+	if !bindQuery(c, &query) {
+		return
+	}
+	id, ok := followTarget(c)
+	if !ok {
+		return
+	}
+	var ids []int
+	followsMu.RLock()
+	for _, follow := range follows {
+		if other, match := pick(follow, id); match {
+			ids = append(ids, other)
+		}
+	}
+	followsMu.RUnlock()
+	result := []PublicUser{}
+	for _, other := range ids {
+		if user, ok := findUserByID(other); ok {
+			result = append(result, toPublicUser(c, user))
+		}
+	}
+	start, end := paginate(c, len(result), query.Page, query.Limit)
+	c.JSON(http.StatusOK, result[start:end])
+}
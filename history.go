@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PostRevision is one version of a post's title and content
+type PostRevision struct {
+	ID       int       `json:"id"`
+	PostID   int       `json:"post_id"`
+	Title    string    `json:"title"`
+	Content  string    `json:"content"`
+	EditorID int       `json:"editor_id"`
+	Created  time.Time `json:"created"`
+}
+
+// Revisions of all posts, guarded by postsMu
+var postRevisions = []PostRevision{}
+var revisionIDSeq atomic.Int64
+
+// Record a change to a post's title or content; the caller holds postsMu.
+// The first edit also stores the original version so it can be reverted to.
+func recordRevision(before, after Post, editorID int) {
+	if before.Title == after.Title && before.Content == after.Content {
+		return
+	}
+	hasHistory := false
+	for _, rev := range postRevisions {
+		if rev.PostID == before.ID {
+			hasHistory = true
+			break
+		}
+	}
+	if !hasHistory {
+		postRevisions = append(postRevisions, PostRevision{
+			ID:       nextID(&revisionIDSeq),
+			PostID:   before.ID,
+			Title:    before.Title,
+			Content:  before.Content,
+			EditorID: before.UserID,
+			Created:  before.Created,
+		})
+	}
+	postRevisions = append(postRevisions, PostRevision{
+		ID:       nextID(&revisionIDSeq),
+		PostID:   after.ID,
+		Title:    after.Title,
+		Content:  after.Content,
+		EditorID: editorID,
+		Created:  time.Now(),
+	})
+}
+
+// Helper function to find a post the authenticated user may manage, writing
+// the error response when there is none; the caller holds postsMu
+func findOwnedPost(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err == nil {
+		for i, post := range posts {
+			if post.ID != id || post.DeletedAt != nil {
+				continue
+			}
+			if !canManagePost(c, post) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed to manage this post"})
+				return 0, false
+			}
+			return i, true
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
+	return 0, false
+}
+
+// Get the edit history of a post, newest first
+func getPostHistory(c *gin.Context) {
+	postsMu.RLock()
+	defer postsMu.RUnlock()
+	i, ok := findOwnedPost(c)
+	if !ok {
+		return
+	}
+	history := []PostRevision{}
+	for j := len(postRevisions) - 1; j >= 0; j-- {
+		if postRevisions[j].PostID == posts[i].ID {
+			history = append(history, postRevisions[j])
+		}
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// Restore a post's title and content from one of its revisions
+func revertPost(c *gin.Context) {
+	postsMu.Lock()
+	defer postsMu.Unlock()
+	i, ok := findOwnedPost(c)
+	if !ok {
+		return
+	}
+	revisionID := c.Param("revisionID")
+	for _, rev := range postRevisions {
+		if rev.PostID == posts[i].ID && strconv.Itoa(rev.ID) == revisionID {
+			before := posts[i]
+			posts[i].Title = rev.Title
+			posts[i].Content = rev.Content
+			now := time.Now()
+			posts[i].Updated = &now
+			editorID, _ := currentUserID(c)
+			recordRevision(before, posts[i], editorID)
+			c.JSON(http.StatusOK, posts[i])
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"message": "Revision not found"})
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportError explains why one record of an import was skipped
+type ImportError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// Import posts from an uploaded JSON file holding an array of posts. Every
+// post is owned by the authenticated user whatever the file says. Invalid
+// records are skipped and reported, unless ?atomic=true is set, in which
+// case any invalid record fails the whole import and nothing is saved.
+func importPosts(c *gin.Context) {
+	var query struct {
+		Atomic bool `form:"atomic"`
+	}
+	if !bindQuery(c, &query) {
+		return
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A JSON file is required in the file field"})
+		return
+	}
+	if header.Size > maxImportBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import file too large"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read import file"})
+		return
+	}
+	defer file.Close()
+	var records []json.RawMessage
+	if err := json.NewDecoder(file).Decode(&records); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Import file must be a JSON array of posts"})
+		return
+	}
+	if len(records) > maxImportRecords {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d posts can be imported at once", maxImportRecords)})
+		return
+	}
+
+	user := mustAuth(c)
+	if user == nil {
+		return
+	}
+	userID := user.ID
+	valid := []Post{}
+	indexes := []int{}
+	importErrors := []ImportError{}
+	for i, record := range records {
+		// Give up on a timed out request before anything is saved
+		if c.Request.Context().Err() != nil {
+			return
+		}
+		var post Post
+		if err := json.Unmarshal(record, &post); err != nil {
+			importErrors = append(importErrors, ImportError{Index: i, Error: importDecodeError(err)})
+			continue
+		}
+		// Normalize like a bound request body, so padding can't dodge validation
+		normalizeStrings(reflect.ValueOf(&post))
+		if msg := importRecordError(post); msg != "" {
+			importErrors = append(importErrors, ImportError{Index: i, Error: msg})
+			continue
+		}
+		if post.Status == "" {
+			post.Status = postStatusPublished
+		}
+		valid = append(valid, Post{Title: post.Title, Content: storedContent(post.Content), Tags: post.Tags, Status: post.Status, UserID: userID})
+		indexes = append(indexes, i)
+	}
+	if query.Atomic && len(importErrors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"imported": 0, "skipped": len(records), "errors": importErrors})
+		return
+	}
+
+	postsMu.Lock()
+	room := len(valid)
+	if user.Role != roleAdmin {
+		room = maxPostsPerUser - countUserPosts(userID)
+	}
+	if query.Atomic && room < len(valid) {
+		postsMu.Unlock()
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("Post limit of %d reached", maxPostsPerUser)})
+		return
+	}
+	imported := []Post{}
+	for n, post := range valid {
+		if n >= room {
+			importErrors = append(importErrors, ImportError{Index: indexes[n], Error: fmt.Sprintf("post limit of %d reached", maxPostsPerUser)})
+			continue
+		}
+		post.ID = nextID(&postIDSeq)
+		post.Created = time.Now()
+		posts = append(posts, post)
+		imported = append(imported, post)
+	}
+	postsMu.Unlock()
+	for _, post := range imported {
+		dispatchEvent(eventPostCreated, post)
+		if post.Status == postStatusPublished {
+			dispatchEvent(eventPostPublished, post)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"imported": len(imported),
+		"skipped":  len(records) - len(imported),
+		"posts":    imported,
+		"errors":   importErrors,
+	})
+}
+
+// Helper function to check one imported post, returning why it's invalid
+func importRecordError(post Post) string {
+	if !validatePostInput(post) {
+		return "title and content are required"
+	}
+	switch post.Status {
+	case "", postStatusDraft, postStatusPublished, postStatusScheduled:
+	default:
+		return "invalid post status"
+	}
+	if utf8.RuneCountInString(post.Content) > maxPostContentLength {
+		return fmt.Sprintf("content must be at most %d characters", maxPostContentLength)
+	}
+	return tagsError(post.Tags)
+}
+
+// Helper function to describe why a record couldn't be decoded into a post
+func importDecodeError(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return typeErr.Field + " must be a " + jsonTypeName(typeErr.Type)
+	}
+	return "post must be a JSON object"
+}
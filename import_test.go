@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Upload a posts import file as the dummy admin
+func importFile(t *testing.T, h http.Handler, query, content string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "posts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/posts/import"+query, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.SetBasicAuth(dummyUser.Username, dummyUser.Password)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestImportPosts(t *testing.T) {
+	file := `[{"title":"one","content":"x"},{"title":"","content":"missing title"}]`
+	tests := []struct {
+		name     string
+		query    string
+		want     int
+		imported int
+	}{
+		{"invalid records are skipped", "", http.StatusOK, 1},
+		{"atomic import saves nothing", "?atomic=true", http.StatusUnprocessableEntity, 0},
+		{"malformed atomic flag", "?atomic=maybe", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetStores(t)
+			r := setupRouter()
+			w := importFile(t, r, tt.query, file)
+			if w.Code != tt.want {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body.String(), tt.want)
+			}
+			postsMu.RLock()
+			n := len(posts)
+			postsMu.RUnlock()
+			if n != tt.imported {
+				t.Errorf("stored %d posts, want %d", n, tt.imported)
+			}
+		})
+	}
+}
+
+func TestImportNormalizesPostsLikeTheAPI(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	w := importFile(t, r, "", `[{"title":"  padded  ","content":"x"},{"title":"   ","content":"blank title"}]`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body.String())
+	}
+	postsMu.RLock()
+	defer postsMu.RUnlock()
+	if len(posts) != 1 || posts[0].Title != "padded" {
+		t.Errorf("stored %+v, want only the padded post, trimmed", posts)
+	}
+}
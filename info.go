@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Build information, injected with
+// go build -ldflags "-X main.version=1.2.3 -X main.commit=$(git rev-parse --short HEAD)"
+var (
+	version = "dev"
+	commit  = "unknown"
+)
+
+// Time the process started, set in main
+var startTime = time.Now()
+
+// Build and runtime information about the running service
+func info(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"version":    version,
+		"commit":     commit,
+		"go_version": runtime.Version(),
+		"uptime":     time.Since(startTime).Round(time.Second).String(),
+	})
+}
+
+// Current server time for clients scheduling posts: the UTC time, the
+// server's timezone and its offset from UTC, and the monotonic uptime
+func serverTime(c *gin.Context) {
+	now := time.Now()
+	zone, offset := now.Zone()
+	c.JSON(http.StatusOK, gin.H{
+		"time":           now.UTC().Format(time.RFC3339),
+		"timezone":       zone,
+		"utc_offset":     offset,
+		"uptime_seconds": time.Since(startTime).Seconds(),
+	})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"io"
+	"log"
+	"log/slog"
+	"log/syslog"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Shared logger for the whole service, writing to the sink picked by LOG_OUTPUT
+var logger = slog.New(slog.NewTextHandler(logWriter(), &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+func init() {
+	// Route the standard log package and Gin's own output through the same sink
+	slog.SetDefault(logger)
+	gin.DefaultWriter = slog.NewLogLogger(logger.Handler(), slog.LevelInfo).Writer()
+	gin.DefaultErrorWriter = slog.NewLogLogger(logger.Handler(), slog.LevelError).Writer()
+	// Only fatal startup errors still go through the log package
+	slog.SetLogLoggerLevel(slog.LevelError)
+	for _, attrs := range invalidSettings {
+		logger.Warn("invalid setting, using the default", attrs...)
+	}
+}
+
+// Open the writer for the configured log destination
+func logWriter() io.Writer {
+	switch logOutput {
+	case "stdout":
+		return os.Stdout
+	case "file":
+		return &lumberjack.Logger{
+			Filename:   logFile,
+			MaxSize:    logMaxSizeMB,
+			MaxBackups: logMaxBackups,
+			MaxAge:     logMaxAgeDays,
+		}
+	case "syslog":
+		w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, "gingo")
+		if err != nil {
+			log.Fatalf("failed to connect to syslog: %v", err)
+		}
+		return w
+	default:
+		log.Fatalf("invalid LOG_OUTPUT %q: want stdout, file or syslog", logOutput)
+		return nil
+	}
+}
@@ -1,24 +1,64 @@
 package main
 
 import (
+	"html/template"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
 )
 
 func main() {
-	r := gin.Default()
-	r.Use(gin.Logger())
-	r.Static("/vendor", "./static/vendor")
-	r.LoadHTMLGlob("templates/**/**")
+	startTime = time.Now()
+	r := setupRouter()
+	tmpl := loadTemplates("templates/**/**")
 
-	r.GET("/", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "views/index.html", gin.H{
+	// HTML pages get their own group so their CSP never mixes with the API's CORS
+	pages := r.Group("/", PageSecurityMiddleware(pageCSP))
+	pages.Static("/vendor", "./static/vendor")
+	pages.GET("/", func(c *gin.Context) {
+		if tmpl == nil {
+			c.String(http.StatusInternalServerError, "Page unavailable")
+			return
+		}
+		c.Render(http.StatusOK, render.HTML{Template: tmpl, Name: "views/index.html", Data: gin.H{
 			"title": "Main website",
-		})
+		}})
 	})
 
-	log.Println("Server started on port 8080")
-	r.Run(":8080")
+	srv := &http.Server{
+		Addr:              ":8080",
+		Handler:           r,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+		MaxHeaderBytes:    maxHeaderBytes,
+	}
+	done := make(chan struct{})
+	go func() {
+		shutdownOnDrain(srv)
+		close(done)
+	}()
+	logStartup(srv)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-done
+}
+
+// Parse the HTML templates. A broken or missing template is logged and nil is
+// returned so the API keeps running, unless STRICT_TEMPLATES asks to fail fast.
+func loadTemplates(pattern string) *template.Template {
+	tmpl, err := template.New("").ParseGlob(pattern)
+	if err != nil {
+		if strictTemplates {
+			log.Fatalf("failed to load templates: %v", err)
+		}
+		logger.Error("failed to load templates, HTML pages are disabled", "error", err)
+		return nil
+	}
+	return tmpl
 }
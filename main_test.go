@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	// Tests create their own admins when they need one
+	firstUserAdmin = false
+	os.Exit(m.Run())
+}
+
+// Reset the in-memory stores so each test starts from an empty system
+func resetStores(t *testing.T) {
+	t.Helper()
+	usersMu.Lock()
+	users = []User{}
+	firstUserClaimed = false
+	usersMu.Unlock()
+	postsMu.Lock()
+	posts = []Post{}
+	postRevisions = []PostRevision{}
+	postsMu.Unlock()
+	followsMu.Lock()
+	follows = []Follow{}
+	followsMu.Unlock()
+	reportsMu.Lock()
+	reports = []Report{}
+	reportsMu.Unlock()
+	auditMu.Lock()
+	auditLog = []AuditEntry{}
+	auditMu.Unlock()
+	apiKeysMu.Lock()
+	apiKeys = []APIKey{}
+	apiKeysMu.Unlock()
+	notificationsMu.Lock()
+	notifications = []Notification{}
+	notificationsMu.Unlock()
+	quotasMu.Lock()
+	quotas = map[string]*quotaUsage{}
+	quotasSwept = time.Time{}
+	quotasMu.Unlock()
+	userIDSeq.Store(int64(dummyUser.ID))
+	postIDSeq.Store(0)
+}
+
+// Send a request through the handler, using basic auth when a username and
+// password are given
+func request(t *testing.T, h http.Handler, method, path, body string, auth ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if len(auth) == 2 {
+		req.SetBasicAuth(auth[0], auth[1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// Decode a JSON response body into v, failing the test when it can't be
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding %q: %v", w.Body.String(), err)
+	}
+}
+
+// Create a user through the API, failing the test when it isn't created
+func createTestUser(t *testing.T, h http.Handler, username, password string) User {
+	t.Helper()
+	w := request(t, h, http.MethodPost, "/users",
+		`{"username":"`+username+`","email":"`+username+`@example.com","password":"`+password+`"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("creating user %s: %d %s", username, w.Code, w.Body.String())
+	}
+	var user User
+	decodeBody(t, w, &user)
+	return user
+}
+
+// Create a post through the API as the given user, failing the test when it
+// isn't created
+func createTestPost(t *testing.T, h http.Handler, body, username, password string) Post {
+	t.Helper()
+	w := request(t, h, http.MethodPost, "/posts?force=true", body, username, password)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("creating post: %d %s", w.Code, w.Body.String())
+	}
+	var post Post
+	decodeBody(t, w, &post)
+	return post
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+)
+
+// Markdown renderer for post content. Raw HTML and dangerous links are left
+// out of the output (goldmark's default, non-unsafe mode).
+var markdown = goldmark.New()
+
+// HTML policy used to sanitize stored post content, allowing the markup
+// user generated content commonly needs and nothing that can run script
+var contentPolicy = bluemonday.UGCPolicy()
+
+// Sanitize post content for storage when SANITIZE_POST_CONTENT is on
+func storedContent(content string) string {
+	if !sanitizeContent {
+		return content
+	}
+	return contentPolicy.Sanitize(content)
+}
+
+func init() {
+	// Fail at startup rather than with a divide by zero in readingTime
+	if readingWordsPerMinute < 1 {
+		log.Fatalf("invalid READING_WORDS_PER_MINUTE %d: must be at least 1", readingWordsPerMinute)
+	}
+}
+
+// Render post content from markdown to HTML
+func renderMarkdown(content string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(content), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Helper function to count the words in post content
+func wordCount(content string) int {
+	return len(strings.Fields(content))
+}
+
+// Helper function to estimate reading time in whole minutes, at least one
+func readingTime(words int) int {
+	minutes := (words + readingWordsPerMinute - 1) / readingWordsPerMinute
+	if minutes < 1 {
+		minutes = 1
+	}
+	return minutes
+}
+
+// Reject post content over the configured length with a 422
+func checkContentLength(c *gin.Context, content string) bool {
+	if utf8.RuneCountInString(content) <= maxPostContentLength {
+		return true
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error": "Validation failed",
+		"fields": []FieldError{{
+			Field:   "content",
+			Rule:    "max",
+			Message: fmt.Sprintf("must be at most %d characters", maxPostContentLength),
+		}},
+	})
+	return false
+}
+
+// Render a post preview without saving anything
+func previewPost(c *gin.Context) {
+	var input struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := bindJSON(c, &input); err != nil {
+		handleBindError(c, err)
+		return
+	}
+	if !checkContentLength(c, input.Content) {
+		return
+	}
+	html, err := renderMarkdown(input.Content)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render content"})
+		return
+	}
+	words := wordCount(input.Content)
+	c.JSON(http.StatusOK, gin.H{
+		"html":         html,
+		"word_count":   words,
+		"reading_time": readingTime(words),
+	})
+}
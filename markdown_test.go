@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestStoredContentIsSanitizedWhenEnabled(t *testing.T) {
+	const content = `<p>hello <b>world</b></p><script>alert(1)</script>`
+	tests := []struct {
+		name       string
+		sanitize   bool
+		wantScript bool
+	}{
+		{"sanitization on", true, false},
+		{"sanitization off keeps raw content", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetStores(t)
+			old := sanitizeContent
+			sanitizeContent = tt.sanitize
+			t.Cleanup(func() { sanitizeContent = old })
+			r := setupRouter()
+
+			post := createTestPost(t, r, `{"title":"t","content":"`+strings.ReplaceAll(content, `"`, `\"`)+`"}`, dummyUser.Username, dummyUser.Password)
+			postsMu.RLock()
+			stored := findPostByID(post.ID, false).Content
+			postsMu.RUnlock()
+			if got := strings.Contains(stored, "<script>"); got != tt.wantScript {
+				t.Errorf("stored content %q: contains <script> = %v, want %v", stored, got, tt.wantScript)
+			}
+			if !strings.Contains(stored, "<b>world</b>") {
+				t.Errorf("stored content %q lost safe markup", stored)
+			}
+
+			w := request(t, r, http.MethodPatch, "/posts/"+strconv.Itoa(post.ID), `{"content":"<script>x()</script>kept"}`, dummyUser.Username, dummyUser.Password)
+			if w.Code != http.StatusOK {
+				t.Fatalf("patch: got %d %s", w.Code, w.Body.String())
+			}
+			postsMu.RLock()
+			stored = findPostByID(post.ID, false).Content
+			postsMu.RUnlock()
+			if got := strings.Contains(stored, "<script>"); got != tt.wantScript {
+				t.Errorf("patched content %q: contains <script> = %v, want %v", stored, got, tt.wantScript)
+			}
+		})
+	}
+}
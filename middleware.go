@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware that gives every request an ID, reusing a valid incoming
+// X-Request-ID, and echoes it back in the response
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if id == "" || len(id) > 64 {
+			id = newRequestID()
+		}
+		c.Set("request_id", id)
+		c.Header("X-Request-ID", id)
+		c.Next()
+	}
+}
+
+// Generate a random request ID
+func newRequestID() string {
+	return randomHex(8)
+}
+
+// Generate n random bytes, hex encoded
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Fields whose values are never written to the logs, matched ignoring case
+var sensitiveFields = map[string]bool{
+	"password":      true,
+	"key":           true,
+	"secret":        true,
+	"token":         true,
+	"authorization": true,
+}
+
+// Response writer that keeps a copy of everything written to the client
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyLogWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyLogWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Middleware that logs request and response bodies, only in debug mode
+func BodyLoggerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if gin.Mode() != gin.DebugMode {
+			c.Next()
+			return
+		}
+		var reqBody []byte
+		if c.Request.Body != nil {
+			var err error
+			if reqBody, err = io.ReadAll(c.Request.Body); err != nil {
+				// Don't hand a body cut short by BodyLimit to the handler
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) {
+					c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+				} else {
+					c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+				}
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(reqBody))
+		}
+		w := &bodyLogWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		logger.Debug("request body", "method", c.Request.Method, "path", c.Request.URL.Path, "body", formatLoggedBody(reqBody))
+		logger.Debug("response body", "method", c.Request.Method, "path", c.Request.URL.Path, "body", formatLoggedBody(w.body.Bytes()))
+	}
+}
+
+// Redact sensitive fields and truncate a body for logging
+func formatLoggedBody(body []byte) string {
+	var data interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if jsonUseNumber {
+		dec.UseNumber()
+	}
+	if err := dec.Decode(&data); err == nil {
+		if redacted, err := json.Marshal(redactFields(data)); err == nil {
+			body = redacted
+		}
+	}
+	if len(body) > debugBodyLogLimit {
+		return string(body[:debugBodyLogLimit]) + "...(truncated)"
+	}
+	return string(body)
+}
+
+// Replace the values of sensitive fields anywhere in a decoded JSON value
+func redactFields(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if sensitiveFields[strings.ToLower(key)] {
+				v[key] = "[REDACTED]"
+			} else {
+				v[key] = redactFields(val)
+			}
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = redactFields(val)
+		}
+	}
+	return data
+}
+
+// Middleware that restricts access by client IP. Denied ranges always win;
+// an empty allowlist lets every other address through.
+func IPFilterMiddleware(allow, deny []*net.IPNet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := net.ParseIP(c.ClientIP())
+		if ip == nil || ipInRanges(ip, deny) || (len(allow) > 0 && !ipInRanges(ip, allow)) {
+			abortForbidden(c, "ip_not_allowed", "access from this address is not allowed")
+			return
+		}
+		c.Next()
+	}
+}
+
+// Check whether an IP falls inside any of the ranges
+func ipInRanges(ip net.IP, ranges []*net.IPNet) bool {
+	for _, r := range ranges {
+		if r.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse a comma separated list of CIDRs or plain IPv4/IPv6 addresses
+func parseIPRanges(list string) []*net.IPNet {
+	var ranges []*net.IPNet
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, r, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Fatalf("invalid IP range %q: %v", entry, err)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// Response writer that adds X-Response-Time just before the headers go out
+type responseTimeWriter struct {
+	gin.ResponseWriter
+	start time.Time
+}
+
+func (w *responseTimeWriter) setHeader() {
+	if !w.Written() {
+		ms := float64(time.Since(w.start).Microseconds()) / 1000
+		w.Header().Set("X-Response-Time", strconv.FormatFloat(ms, 'f', 2, 64)+"ms")
+	}
+}
+
+func (w *responseTimeWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *responseTimeWriter) Write(b []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseTimeWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Middleware that reports the handler duration in the X-Response-Time header.
+// It reuses the start time recorded by LoggerMiddleware when that runs first.
+func ResponseTimeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		if t, ok := c.Get("request_start"); ok {
+			start = t.(time.Time)
+		}
+		c.Writer = &responseTimeWriter{ResponseWriter: c.Writer, start: start}
+		c.Next()
+		c.Writer.WriteHeaderNow()
+	}
+}
+
+// Middleware that limits request body sizes. Requests that declare a larger
+// Content-Length are rejected with 413 before the body is read, and the body
+// is wrapped in MaxBytesReader for clients that don't declare a size. Routes
+// can set their own limit in routeBodyLimits, keyed by route path.
+func BodyLimitMiddleware(defaultLimit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := defaultLimit
+		if l, ok := routeBodyLimits[c.FullPath()]; ok {
+			limit = l
+		}
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// Response writer that holds back the body so JSON can be re-indented
+type prettyJSONWriter struct {
+	gin.ResponseWriter
+	body    bytes.Buffer
+	written bool
+}
+
+func (w *prettyJSONWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.body.Write(b)
+}
+
+func (w *prettyJSONWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+// Report a held back body as written, so later middleware such as the
+// timeout doesn't add a second response to it
+func (w *prettyJSONWriter) Written() bool {
+	return w.written || w.ResponseWriter.Written()
+}
+
+// Send the held back body, indented when it is JSON
+func (w *prettyJSONWriter) flush() {
+	body := w.body.Bytes()
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, "", "    "); err == nil {
+			indented.WriteByte('\n')
+			body = indented.Bytes()
+		}
+	}
+	if len(body) > 0 {
+		w.ResponseWriter.Write(body)
+	}
+}
+
+// Middleware that pretty-prints JSON responses for ?pretty=true, the same way
+// c.IndentedJSON does. Responses without the parameter are left untouched.
+func PrettyJSONMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if pretty, _ := strconv.ParseBool(c.Query("pretty")); !pretty {
+			c.Next()
+			return
+		}
+		w := &prettyJSONWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		w.flush()
+	}
+}
+
+// Middleware that gives each request a deadline through its context and
+// answers 504 when the deadline passes before the handler responds.
+// Handlers stop early by watching c.Request.Context(); work that ignores the
+// context still runs to completion. Streaming routes must not be put behind
+// it, as their connections are expected to outlive any deadline.
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+		if ctx.Err() == context.DeadlineExceeded && !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": "Request timed out"})
+		}
+	}
+}
+
+// Middleware that lets at most limit requests through at once; every route
+// it is attached to shares the same slots. Requests over the limit wait for
+// a free slot when wait is set (until their context ends) and are answered
+// 503 straight away otherwise. The slot is released even if the handler panics.
+func ConcurrencyLimitMiddleware(limit int, wait bool) gin.HandlerFunc {
+	if limit <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	slots := make(chan struct{}, limit)
+	return func(c *gin.Context) {
+		acquired := false
+		if wait {
+			select {
+			case slots <- struct{}{}:
+				acquired = true
+			case <-c.Request.Context().Done():
+			}
+		} else {
+			select {
+			case slots <- struct{}{}:
+				acquired = true
+			default:
+			}
+		}
+		if !acquired {
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Server busy, try again later"})
+			return
+		}
+		defer func() { <-slots }()
+		c.Next()
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeoutsApplyPerGroup(t *testing.T) {
+	// Work that takes 100ms unless its context ends first
+	work := func(c *gin.Context) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+			c.JSON(http.StatusOK, gin.H{"done": true})
+		case <-c.Request.Context().Done():
+		}
+	}
+	// Work that ignores its context and answers after the deadline
+	late := func(c *gin.Context) {
+		time.Sleep(50 * time.Millisecond)
+		c.JSON(http.StatusOK, gin.H{"done": true})
+	}
+	r := gin.New()
+	r.Use(PrettyJSONMiddleware())
+	short := r.Group("/short", TimeoutMiddleware(10*time.Millisecond))
+	short.GET("/work", work)
+	short.GET("/late", late)
+	r.Group("/long", TimeoutMiddleware(time.Second)).GET("/work", work)
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"slow handler in the short group times out", "/short/work", http.StatusGatewayTimeout},
+		{"same handler in the long group succeeds", "/long/work", http.StatusOK},
+		{"late response is sent as is", "/short/late", http.StatusOK},
+		{"late pretty response gets no 504 appended", "/short/late?pretty=true", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.want {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body.String(), tt.want)
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Errorf("body is not a single JSON object: %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestLoggedBodiesAreRedacted(t *testing.T) {
+	body := `{"username":"jane","Password":"hunter2","profile":{"TOKEN":"t0k3n","note":"kept"},` +
+		`"keys":[{"key":"k3y","secret":"s3cret"}],"Authorization":"Basic abc"}`
+	logged := formatLoggedBody([]byte(body))
+	for _, secret := range []string{"hunter2", "t0k3n", "k3y", "s3cret", "Basic abc"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("logged body leaks %q: %s", secret, logged)
+		}
+	}
+	for _, kept := range []string{"jane", "kept"} {
+		if !strings.Contains(logged, kept) {
+			t.Errorf("logged body lost %q: %s", kept, logged)
+		}
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	serve := func(r http.Handler, path string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+	const limit = 2
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	r := gin.New()
+	r.Use(gin.Recovery(), ConcurrencyLimitMiddleware(limit, false))
+	r.GET("/block", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	codes := make(chan int, limit)
+	for i := 0; i < limit; i++ {
+		go func() { codes <- serve(r, "/block") }()
+		<-entered
+	}
+	if code := serve(r, "/ok"); code != http.StatusServiceUnavailable {
+		t.Errorf("request over the limit: got %d, want 503", code)
+	}
+	close(release)
+	for i := 0; i < limit; i++ {
+		if code := <-codes; code != http.StatusOK {
+			t.Errorf("request within the limit: got %d, want 200", code)
+		}
+	}
+
+	for i := 0; i < limit+1; i++ {
+		if code := serve(r, "/panic"); code != http.StatusInternalServerError {
+			t.Fatalf("panicking handler: got %d, want 500", code)
+		}
+	}
+	if code := serve(r, "/ok"); code != http.StatusOK {
+		t.Errorf("slots leaked after panics: got %d, want 200", code)
+	}
+}
+
+func TestOversizedBodiesAreRejectedWhileLoggingBodies(t *testing.T) {
+	gin.SetMode(gin.DebugMode)
+	defer gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(BodyLimitMiddleware(10), BodyLoggerMiddleware())
+	r.POST("/echo", func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.String(http.StatusOK, "%s", body)
+	})
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"within the limit", "0123456789", http.StatusOK},
+		{"over the limit without Content-Length", strings.Repeat("x", 100), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(tt.body))
+			req.ContentLength = -1 // sent chunked, so only MaxBytesReader can catch it
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body.String(), tt.want)
+			}
+			if tt.want == http.StatusOK && w.Body.String() != tt.body {
+				t.Errorf("handler got %q, want %q", w.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestCORSExposesResponseHeaders(t *testing.T) {
+	auth := authCORS
+	auth.AllowOrigins = []string{"https://app.example.com"}
+	r := gin.New()
+	handler := func(c *gin.Context) {
+		c.Header("ETag", `"v1"`)
+		c.JSON(http.StatusOK, gin.H{})
+	}
+	r.Group("/public", CORSMiddleware(publicCORS)).GET("/thing", handler)
+	r.Group("/auth", CORSMiddleware(auth)).GET("/thing", handler)
+
+	for _, path := range []string{"/public/thing", "/auth/thing"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		exposed := w.Header().Get("Access-Control-Expose-Headers")
+		for _, header := range []string{"Link", "X-Total-Count", "ETag", "Location", "X-Request-ID"} {
+			if !strings.Contains(exposed, header) {
+				t.Errorf("%s: %s is not exposed in %q", path, header, exposed)
+			}
+		}
+	}
+
+	askPreflight := func(opts CORSOptions, method string) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.Use(CORSMiddleware(opts))
+		req := httptest.NewRequest(http.MethodOptions, "/thing", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", method)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	allowed := askPreflight(auth, http.MethodPut).Header().Get("Access-Control-Allow-Headers")
+	for _, header := range []string{"If-Match", "If-None-Match"} {
+		if !strings.Contains(allowed, header) {
+			t.Errorf("authenticated preflight doesn't allow %s: %q", header, allowed)
+		}
+	}
+	methods := askPreflight(publicCORS, http.MethodGet).Header().Get("Access-Control-Allow-Methods")
+	if strings.Contains(methods, "PUT") || strings.Contains(methods, "DELETE") {
+		t.Errorf("public preflight allows writes the public group doesn't serve: %q", methods)
+	}
+}
+
+func TestQuotasForgetClientsWhoseWindowEnded(t *testing.T) {
+	resetStores(t)
+	window := apiQuotaWindow
+	apiQuotaWindow = 20 * time.Millisecond
+	t.Cleanup(func() { apiQuotaWindow = window })
+	r := gin.New()
+	r.Use(QuotaMiddleware())
+	r.GET("/thing", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	call := func(ip string) {
+		req := httptest.NewRequest(http.MethodGet, "/thing", nil)
+		req.RemoteAddr = ip + ":1234"
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	for _, ip := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"} {
+		call(ip)
+	}
+	time.Sleep(2 * apiQuotaWindow)
+	call("192.0.2.4")
+
+	quotasMu.Lock()
+	defer quotasMu.Unlock()
+	if len(quotas) != 1 || quotas["ip:192.0.2.4"] == nil {
+		t.Errorf("expired quota windows are still tracked: %v", quotas)
+	}
+}
+
+func TestSchemaValidationRejectsOversizedBodies(t *testing.T) {
+	enabled := schemaValidation
+	schemaValidation = true
+	t.Cleanup(func() { schemaValidation = enabled })
+	r := gin.New()
+	r.Use(BodyLimitMiddleware(10))
+	r.POST("/posts", SchemaMiddleware("post"), func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	req := httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(`{"title":"`+strings.Repeat("x", 100)+`"}`))
+	req.ContentLength = -1 // sent chunked, so only MaxBytesReader can catch it
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("got %d %s, want 413", w.Code, w.Body.String())
+	}
+}
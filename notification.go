@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Notification tells a user about something another user did that affects them
+type Notification struct {
+	ID      int                    `json:"id"`
+	UserID  int                    `json:"user_id"`
+	Type    string                 `json:"type"`
+	Payload map[string]interface{} `json:"payload,omitempty"`
+	Read    bool                   `json:"read"`
+	Created time.Time              `json:"created"`
+}
+
+// Kinds of notification
+const (
+	notificationFollow = "follow"
+)
+
+var notifications = []Notification{}
+var notificationIDSeq atomic.Int64
+var notificationsMu sync.RWMutex
+
+// Record a new unread notification for a user
+func notify(userID int, kind string, payload map[string]interface{}) {
+	notificationsMu.Lock()
+	defer notificationsMu.Unlock()
+	notifications = append(notifications, Notification{
+		ID:      nextID(&notificationIDSeq),
+		UserID:  userID,
+		Type:    kind,
+		Payload: payload,
+		Created: time.Now(),
+	})
+}
+
+// Remove all notifications of a deleted user
+func removeNotifications(userID int) {
+	notificationsMu.Lock()
+	defer notificationsMu.Unlock()
+	kept := notifications[:0]
+	for _, n := range notifications {
+		if n.UserID != userID {
+			kept = append(kept, n)
+		}
+	}
+	notifications = kept
+}
+
+// Get the authenticated user's notifications, newest first; ?unread=true
+// leaves out the ones already read
+func getNotifications(c *gin.Context) {
+	var query struct {
+		Pagination
+		Unread bool `form:"unread"`
+	}
+	if !bindQuery(c, &query) {
+		return
+	}
+	user := mustAuth(c)
+	if user == nil {
+		return
+	}
+	result := []Notification{}
+	notificationsMu.RLock()
+	for i := len(notifications) - 1; i >= 0; i-- {
+		n := notifications[i]
+		if n.UserID == user.ID && !(query.Unread && n.Read) {
+			result = append(result, n)
+		}
+	}
+	notificationsMu.RUnlock()
+	start, end := paginate(c, len(result), query.Page, query.Limit)
+	c.JSON(http.StatusOK, result[start:end])
+}
+
+// Mark all of the authenticated user's notifications as read
+func markNotificationsRead(c *gin.Context) {
+	user := mustAuth(c)
+	if user == nil {
+		return
+	}
+	updated := 0
+	notificationsMu.Lock()
+	for i, n := range notifications {
+		if n.UserID == user.ID && !n.Read {
+			notifications[i].Read = true
+			updated++
+		}
+	}
+	notificationsMu.Unlock()
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
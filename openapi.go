@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Serve an OpenAPI 3.1 document generated from the routes registered on
+// router, so it can't drift from them. It is built on the first request,
+// once every route (including the ones main adds) is registered.
+func openAPIHandler(router *gin.Engine) gin.HandlerFunc {
+	var once sync.Once
+	var spec gin.H
+	return func(c *gin.Context) {
+		once.Do(func() { spec = buildOpenAPISpec(router.Routes()) })
+		c.JSON(http.StatusOK, spec)
+	}
+}
+
+// Helper function to describe routes as OpenAPI paths. Preflight routes are
+// left out. Authentication is optional at the document level because only
+// some routes need it; those answer 401 without credentials.
+func buildOpenAPISpec(routes gin.RoutesInfo) gin.H {
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	paths := gin.H{}
+	for _, route := range routes {
+		if route.Method == http.MethodOptions || route.Method == http.MethodHead {
+			continue
+		}
+		path, params := openAPIPath(route.Path)
+		operation := gin.H{
+			"responses": gin.H{"default": gin.H{"description": "JSON response; errors carry an error or message field"}},
+		}
+		if name := strings.TrimPrefix(route.Handler, "main."); !strings.Contains(name, ".") {
+			operation["operationId"] = name
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		item, ok := paths[path].(gin.H)
+		if !ok {
+			item = gin.H{}
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation
+	}
+	return gin.H{
+		"openapi": "3.1.0",
+		"info":    gin.H{"title": siteTitle + " API", "version": version},
+		"paths":   paths,
+		"components": gin.H{
+			"securitySchemes": gin.H{"basicAuth": gin.H{"type": "http", "scheme": "basic"}},
+		},
+		"security": []gin.H{{}, {"basicAuth": []string{}}},
+	}
+}
+
+// Helper function to turn a Gin path into an OpenAPI one (":id" becomes
+// "{id}", "*file" becomes "{file}") along with its path parameters
+func openAPIPath(path string) (string, []gin.H) {
+	segments := strings.Split(path, "/")
+	params := []gin.H{}
+	for i, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, gin.H{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   gin.H{"type": "string"},
+		})
+	}
+	return strings.Join(segments, "/"), params
+}
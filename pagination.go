@@ -0,0 +1,239 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// Pagination holds the page and limit query parameters of a list endpoint.
+// Query structs embed it to get parsing, defaults and clamping from bindQuery.
+type Pagination struct {
+	Page  int `form:"page" binding:"omitempty,min=1"`
+	Limit int `form:"limit" binding:"omitempty,min=1"`
+}
+
+func (p *Pagination) pagination() *Pagination { return p }
+
+// Implemented by query structs that embed Pagination
+type paginated interface {
+	pagination() *Pagination
+}
+
+// Bind the query string into a typed struct and validate it, writing a 400
+// with the offending fields when it doesn't parse. Embedded Pagination gets
+// defaults and oversized limits are clamped to maxLimit.
+func bindQuery(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindQuery(obj); err != nil {
+		handleQueryError(c, err)
+		return false
+	}
+	if p, ok := obj.(paginated); ok {
+		page := p.pagination()
+		if page.Page == 0 {
+			page.Page = 1
+		}
+		if page.Limit == 0 {
+			page.Limit = defaultLimit
+		}
+		if page.Limit > maxLimit {
+			page.Limit = maxLimit
+		}
+	}
+	return true
+}
+
+// Error handler for query binding errors
+func handleQueryError(c *gin.Context, err error) {
+	var numErr *strconv.NumError
+	var validationErrs validator.ValidationErrors
+	fields := []FieldError{}
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldError{
+				Field:   fe.Field(),
+				Rule:    fe.Tag(),
+				Message: validationMessage(fe),
+			})
+		}
+	case errors.As(err, &numErr):
+		expected := "expected number"
+		if numErr.Func == "ParseBool" {
+			expected = "expected boolean"
+		}
+		fields = append(fields, FieldError{
+			Field:   queryParamWithValue(c, numErr.Num),
+			Rule:    "type",
+			Message: expected,
+		})
+	default:
+		fields = append(fields, FieldError{Rule: "type", Message: err.Error()})
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "fields": fields})
+}
+
+// Find the name of the query parameter carrying a value that failed to parse
+func queryParamWithValue(c *gin.Context, value string) string {
+	for name, values := range c.Request.URL.Query() {
+		for _, v := range values {
+			if v == value {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// Work out the slice bounds of the requested page and set the
+// X-Total-Count and RFC 5988 Link headers describing it
+func paginate(c *gin.Context, total, page, limit int) (start, end int) {
+	lastPage := (total + limit - 1) / limit
+	if lastPage < 1 {
+		lastPage = 1
+	}
+	links := []string{
+		pageLink(c, 1, limit, "first"),
+		pageLink(c, lastPage, limit, "last"),
+	}
+	if page > 1 {
+		links = append(links, pageLink(c, page-1, limit, "prev"))
+	}
+	if page < lastPage {
+		links = append(links, pageLink(c, page+1, limit, "next"))
+	}
+	c.Header("Link", strings.Join(links, ", "))
+	c.Header("X-Total-Count", strconv.Itoa(total))
+
+	start = (page - 1) * limit
+	if start > total {
+		start = total
+	}
+	end = start + limit
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+// Build a Link header entry for a page, keeping the other query parameters
+func pageLink(c *gin.Context, page, limit int, rel string) string {
+	u := *c.Request.URL
+	q := u.Query()
+	q.Set("page", strconv.Itoa(page))
+	q.Set("limit", strconv.Itoa(limit))
+	u.RawQuery = q.Encode()
+	return fmt.Sprintf(`<%s>; rel="%s"`, u.RequestURI(), rel)
+}
+
+// Order posts newest first, breaking ties on ID so pages stay consistent
+// across requests when posts share a timestamp
+func newerPost(a, b Post) bool {
+	if !a.Created.Equal(b.Created) {
+		return a.Created.After(b.Created)
+	}
+	return a.ID > b.ID
+}
+
+// Get every value of a query parameter that may be repeated
+// (?expand=a&expand=b) or comma separated (?expand=a,b), or both
+func queryList(c *gin.Context, key string) []string {
+	return splitList(strings.Join(c.QueryArray(key), ","))
+}
+
+// Sorting holds the sort query parameter: a field name, prefixed with "-" for
+// descending order. Query structs embed it next to Pagination.
+type Sorting struct {
+	Sort string `form:"sort"`
+}
+
+// A field list items can be sorted by. adminOnly fields hold sensitive data
+// whose order alone could leak it, so only admins may sort on them.
+type sortField[T any] struct {
+	compare   func(a, b T) int
+	adminOnly bool
+}
+
+// Fields posts and users can be sorted by. Ties are always broken on ID in
+// the same direction so pages stay stable.
+var (
+	postSortFields = map[string]sortField[Post]{
+		"id":      {compare: func(a, b Post) int { return cmp.Compare(a.ID, b.ID) }},
+		"created": {compare: func(a, b Post) int { return a.Created.Compare(b.Created) }},
+		"title":   {compare: func(a, b Post) int { return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title)) }},
+		"likes":   {compare: func(a, b Post) int { return cmp.Compare(a.Likes, b.Likes) }},
+		"views":   {compare: func(a, b Post) int { return cmp.Compare(a.Views, b.Views) }},
+	}
+	userSortFields = map[string]sortField[User]{
+		"id":       {compare: func(a, b User) int { return cmp.Compare(a.ID, b.ID) }},
+		"username": {compare: func(a, b User) int { return strings.Compare(strings.ToLower(a.Username), strings.ToLower(b.Username)) }},
+		"created":  {compare: func(a, b User) int { return a.Created.Compare(b.Created) }},
+		"email":    {compare: func(a, b User) int { return strings.Compare(strings.ToLower(a.Email), strings.ToLower(b.Email)) }, adminOnly: true},
+	}
+)
+
+func init() {
+	// Fail at startup rather than on every request when a default sort is misconfigured
+	if _, ok := postSortFields[strings.TrimPrefix(postsDefaultSort, "-")]; !ok {
+		log.Fatalf("invalid POSTS_DEFAULT_SORT %q", postsDefaultSort)
+	}
+	if _, ok := userSortFields[strings.TrimPrefix(usersDefaultSort, "-")]; !ok {
+		log.Fatalf("invalid USERS_DEFAULT_SORT %q", usersDefaultSort)
+	}
+	// paginate divides by the limit, so neither page size may be below 1
+	if defaultLimit < 1 {
+		log.Fatalf("invalid DEFAULT_PAGE_LIMIT %d: must be at least 1", defaultLimit)
+	}
+	if maxLimit < 1 {
+		log.Fatalf("invalid MAX_PAGE_LIMIT %d: must be at least 1", maxLimit)
+	}
+}
+
+// Sort items by the requested field, or by def when the request doesn't ask
+// for one. Writes a 400 when the field isn't one of fields, and a 403 when
+// it is admin-only and the caller isn't an admin.
+func applySort[T any](c *gin.Context, items []T, fields map[string]sortField[T], sort, def string) bool {
+	if sort == "" {
+		sort = def
+	}
+	name := strings.TrimPrefix(sort, "-")
+	field, ok := fields[name]
+	if !ok {
+		names := make([]string, 0, len(fields))
+		for n := range fields {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "fields": []FieldError{{
+			Field:   "sort",
+			Rule:    "oneof",
+			Message: "must be one of: " + strings.Join(names, " ") + ", optionally prefixed with -",
+		}}})
+		return false
+	}
+	if field.adminOnly && !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed to sort by " + name})
+		return false
+	}
+	byID := fields["id"].compare
+	desc := strings.HasPrefix(sort, "-")
+	slices.SortStableFunc(items, func(a, b T) int {
+		r := field.compare(a, b)
+		if r == 0 {
+			r = byID(a, b)
+		}
+		if desc {
+			r = -r
+		}
+		return r
+	})
+	return true
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPostOrderIsStableForIdenticalTimestamps(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	postsMu.Lock()
+	for i := 0; i < 9; i++ {
+		posts = append(posts, Post{ID: nextID(&postIDSeq), Title: "same time", Content: "x",
+			UserID: dummyUser.ID, Status: postStatusPublished, Created: created})
+	}
+	postsMu.Unlock()
+
+	listIDs := func(path string) []int {
+		w := request(t, r, http.MethodGet, path, "", dummyUser.Username, dummyUser.Password)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: %d %s", path, w.Code, w.Body.String())
+		}
+		var items []PostListItem
+		decodeBody(t, w, &items)
+		ids := make([]int, 0, len(items))
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	for _, sort := range []string{"created", "-created"} {
+		all := listIDs("/posts?limit=100&sort=" + sort)
+		for i := 0; i < 5; i++ {
+			if again := listIDs("/posts?limit=100&sort=" + sort); !slices.Equal(again, all) {
+				t.Fatalf("sort=%s: order changed between calls: %v then %v", sort, all, again)
+			}
+		}
+		var paged []int
+		for page := 1; page <= 3; page++ {
+			paged = append(paged, listIDs("/posts?limit=3&sort="+sort+"&page="+strconv.Itoa(page))...)
+		}
+		if !slices.Equal(paged, all) {
+			t.Errorf("sort=%s: pages %v don't match the full list %v", sort, paged, all)
+		}
+		if !slices.IsSorted(all) && !slices.IsSortedFunc(all, func(a, b int) int { return b - a }) {
+			t.Errorf("sort=%s: ties not broken by ID: %v", sort, all)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pin a post so lists and feeds show it first; at most maxPinnedPosts at a time
+func pinPost(c *gin.Context) {
+	setPinned(c, true)
+}
+
+// Unpin a post
+func unpinPost(c *gin.Context) {
+	setPinned(c, false)
+}
+
+// Helper function to pin or unpin the :id post. Pinning a pinned post (or
+// unpinning an unpinned one) is a no-op.
+func setPinned(c *gin.Context, pinned bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
+		return
+	}
+	postsMu.Lock()
+	defer postsMu.Unlock()
+	for i, post := range posts {
+		if post.ID != id || post.DeletedAt != nil {
+			continue
+		}
+		if post.Pinned == pinned {
+			c.JSON(http.StatusOK, post)
+			return
+		}
+		if pinned && countPinnedPosts() >= maxPinnedPosts {
+			c.JSON(http.StatusConflict, gin.H{"error": "At most " + strconv.Itoa(maxPinnedPosts) + " posts can be pinned"})
+			return
+		}
+		posts[i].Pinned = pinned
+		action := "post.unpinned"
+		if pinned {
+			action = "post.pinned"
+		}
+		recordAudit(c, action, "post", id, "")
+		c.JSON(http.StatusOK, posts[i])
+		return
+	}
+	c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
+}
+
+// Helper function to count the live pinned posts; the caller holds postsMu
+func countPinnedPosts() int {
+	count := 0
+	for _, post := range posts {
+		if post.Pinned && post.DeletedAt == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Move pinned posts to the front of an already sorted list, keeping the
+// order within pinned and unpinned posts
+func pinnedFirst(list []Post) {
+	slices.SortStableFunc(list, func(a, b Post) int {
+		switch {
+		case a.Pinned == b.Pinned:
+			return 0
+		case a.Pinned:
+			return -1
+		default:
+			return 1
+		}
+	})
+}
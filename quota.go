@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Requests counted against one quota key in the current window
+type quotaUsage struct {
+	count int
+	reset time.Time
+}
+
+var (
+	quotas      = map[string]*quotaUsage{}
+	quotasSwept time.Time
+	quotasMu    sync.Mutex
+)
+
+// Drop the usage of keys whose window has ended, at most once per window, so
+// clients that stop calling don't stay in the map. Callers hold quotasMu.
+func sweepQuotas(now time.Time) {
+	if now.Sub(quotasSwept) < apiQuotaWindow {
+		return
+	}
+	for key, usage := range quotas {
+		if !now.Before(usage.reset) {
+			delete(quotas, key)
+		}
+	}
+	quotasSwept = now
+}
+
+// Middleware that enforces apiQuota requests per apiQuotaWindow. Requests are
+// counted per authenticated user, or per client IP when there is none, so it
+// must run after AuthMiddleware on authenticated routes. The health check is
+// never counted so monitoring can't exhaust a quota.
+func QuotaMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiQuota <= 0 || c.FullPath() == "/healthz" {
+			c.Next()
+			return
+		}
+		key := "ip:" + c.ClientIP()
+		if id, ok := currentUserID(c); ok {
+			key = fmt.Sprintf("user:%d", id)
+		}
+		now := time.Now()
+		quotasMu.Lock()
+		sweepQuotas(now)
+		usage, ok := quotas[key]
+		if !ok || !now.Before(usage.reset) {
+			usage = &quotaUsage{reset: now.Add(apiQuotaWindow)}
+			quotas[key] = usage
+		}
+		allowed := usage.count < apiQuota
+		if allowed {
+			usage.count++
+		}
+		remaining, reset := apiQuota-usage.count, usage.reset
+		quotasMu.Unlock()
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(apiQuota))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "API quota exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Read-only mode blocks every write while reads keep working. It starts
+// from READ_ONLY and can be switched at runtime through the admin API.
+var readOnly atomic.Bool
+
+func init() {
+	readOnly.Store(getEnvBool("READ_ONLY", false))
+}
+
+// Routes that keep accepting writes in read-only mode, so operators can still
+// switch it off and drain the server
+var readOnlyExempt = map[string]bool{
+	"PUT /admin/read-only": true,
+	"POST /admin/drain":    true,
+}
+
+// Middleware that rejects POST, PUT, PATCH and DELETE with 503 in read-only mode
+func ReadOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !readOnly.Load() || readOnlyExempt[c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			logger.Warn("write rejected in read-only mode",
+				"method", c.Request.Method, "path", c.Request.URL.Path, "request_id", c.GetString("request_id"))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Service is in read-only mode"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Get whether read-only mode is on
+func getReadOnly(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"enabled": readOnly.Load()})
+}
+
+// Switch read-only mode on or off
+func setReadOnly(c *gin.Context) {
+	var body struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+	if err := bindJSON(c, &body); err != nil {
+		handleBindError(c, err)
+		return
+	}
+	readOnly.Store(*body.Enabled)
+	recordAudit(c, "read_only.updated", "read_only", 0, strconv.FormatBool(*body.Enabled))
+	c.JSON(http.StatusOK, gin.H{"enabled": *body.Enabled})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Report model represents a user flagging a post for moderation
+type Report struct {
+	ID         int       `json:"id"`
+	PostID     int       `json:"post_id"`
+	ReporterID int       `json:"reporter_id"`
+	Reason     string    `json:"reason"`
+	Created    time.Time `json:"created"`
+	Status     string    `json:"status"`
+}
+
+// Statuses a report can be in
+const (
+	reportStatusOpen     = "open"
+	reportStatusResolved = "resolved"
+)
+
+var reports = []Report{}
+var reportsMu sync.RWMutex
+
+// Report a post as inappropriate
+func reportPost(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	postsMu.RLock()
+	exists := err == nil && findPostByID(postID, false) != nil
+	postsMu.RUnlock()
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
+		return
+	}
+	var newReport Report
+	if err := bindJSON(c, &newReport); err != nil {
+		handleBindError(c, err)
+		return
+	}
+	if newReport.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reason is required"})
+		return
+	}
+	user := mustAuth(c)
+	if user == nil {
+		return
+	}
+	reporterID := user.ID
+	reportsMu.Lock()
+	defer reportsMu.Unlock()
+	for _, report := range reports {
+		if report.PostID == postID && report.ReporterID == reporterID && report.Status == reportStatusOpen {
+			c.JSON(http.StatusConflict, gin.H{"error": "Post already reported"})
+			return
+		}
+	}
+	newReport.ID = nextID(&reportIDSeq)
+	newReport.PostID = postID
+	newReport.ReporterID = reporterID
+	newReport.Status = reportStatusOpen
+	newReport.Created = time.Now()
+	reports = append(reports, newReport)
+	c.JSON(http.StatusCreated, newReport)
+}
+
+// Get reports, optionally filtered by status
+func getReports(c *gin.Context) {
+	var query struct {
+		Pagination
+		Status string `form:"status" binding:"omitempty,oneof=open resolved"`
+	}
+	if !bindQuery(c, &query) {
+		return
+	}
+	result := []Report{}
+	reportsMu.RLock()
+	for _, report := range reports {
+		if query.Status == "" || report.Status == query.Status {
+			result = append(result, report)
+		}
+	}
+	reportsMu.RUnlock()
+	start, end := paginate(c, len(result), query.Page, query.Limit)
+	c.JSON(http.StatusOK, result[start:end])
+}
+
+// Resolve an open report
+func resolveReport(c *gin.Context) {
+	id := c.Param("id")
+	reportsMu.Lock()
+	defer reportsMu.Unlock()
+	for i, report := range reports {
+		if strconv.Itoa(report.ID) == id {
+			reports[i].Status = reportStatusResolved
+			c.JSON(http.StatusOK, reports[i])
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"message": "Report not found"})
+}
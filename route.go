@@ -1,9 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"math/rand/v2"
 	"net/http"
+	"net/mail"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,151 +21,621 @@ import (
 // User model represents a user in the system
 type User struct {
 	ID       int       `json:"id"`
-	Username string    `json:"username"`
-	Email    string    `json:"email"`
+	Username string    `json:"username" normalize:"trim"`
+	Email    string    `json:"email" normalize:"trim"`
 	Password string    `json:"password"`
+	Role     string    `json:"role"`
+	Active   bool      `json:"active"`
+	Created  time.Time `json:"created"`
+}
+
+// PublicUser is a user as shown to other clients, without the password.
+// The email is only filled in for admins and the user themselves.
+type PublicUser struct {
+	ID       int       `json:"id"`
+	Username string    `json:"username"`
+	Email    string    `json:"email,omitempty"`
+	Role     string    `json:"role"`
+	Active   bool      `json:"active"`
 	Created  time.Time `json:"created"`
 }
 
+// Helper function to strip private fields from a user, keeping the email
+// only when the caller is an admin or the user themselves
+func toPublicUser(c *gin.Context, user User) PublicUser {
+	public := PublicUser{
+		ID:       user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+		Active:   user.Active,
+		Created:  user.Created,
+	}
+	if id, ok := currentUserID(c); isAdmin(c) || (ok && id == user.ID) {
+		public.Email = user.Email
+	}
+	return public
+}
+
+// Roles a user can have
+const (
+	roleAdmin = "admin"
+	roleUser  = "user"
+)
+
 // Post model represents a post by a user
 type Post struct {
-	ID      int       `json:"id"`
-	Title   string    `json:"title"`
-	Content string    `json:"content"`
-	UserID  int       `json:"user_id"`
-	Created time.Time `json:"created"`
+	ID        int        `json:"id"`
+	Title     string     `json:"title" normalize:"collapse"`
+	Content   string     `json:"content"`
+	Tags      []string   `json:"tags,omitempty"`
+	UserID    int        `json:"user_id"`
+	Status    string     `json:"status"`
+	Pinned    bool       `json:"pinned"`
+	Likes     int        `json:"likes"`
+	Views     int        `json:"views"`
+	Created   time.Time  `json:"created"`
+	Updated   *time.Time `json:"updated,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
+// Statuses a post can be in
+const (
+	postStatusDraft     = "draft"
+	postStatusPublished = "published"
+	postStatusScheduled = "scheduled"
+)
+
 var users = []User{}
 var posts = []Post{}
 
+// Guard users and posts; handlers hold them while reading or changing the
+// slices, and list handlers copy what they need before paginating
+var (
+	usersMu sync.RWMutex
+	postsMu sync.RWMutex
+)
+
+// Whether a user has already been made admin by FIRST_USER_ADMIN, guarded by
+// usersMu. It is never cleared, so emptying the user store can't hand the
+// admin role to the next anonymous registration.
+var firstUserClaimed bool
+
+// Monotonic ID sequences so IDs are never reused after a delete
+var (
+	userIDSeq   atomic.Int64
+	postIDSeq   atomic.Int64
+	reportIDSeq atomic.Int64
+)
+
+// Generate the next ID from a sequence
+func nextID(seq *atomic.Int64) int {
+	return int(seq.Add(1))
+}
+
 // Dummy user for authentication simulation
 var dummyUser = User{
 	ID:       1,
 	Username: "admin",
 	Email:    "admin@example.com",
 	Password: "password123",
+	Role:     roleAdmin,
+	Active:   true,
 	Created:  time.Now(),
 }
 
-// Middleware for basic authentication
+func init() {
+	// Start after the dummy user so created users never share its ID
+	userIDSeq.Store(int64(dummyUser.ID))
+	if defaultUserRole != roleUser && defaultUserRole != roleAdmin {
+		log.Fatalf("invalid DEFAULT_USER_ROLE %q", defaultUserRole)
+	}
+}
+
+// Middleware for basic or API key authentication
 func AuthMiddleware() gin.HandlerFunc {
+	return basicAuth(true)
+}
+
+// Middleware that authenticates requests carrying basic auth credentials and
+// lets anonymous ones through; wrong credentials are still rejected
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return basicAuth(false)
+}
+
+// Basic or API key authentication, answering 401 for missing credentials
+// when required
+func basicAuth(required bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		var user User
 		username, password, ok := c.Request.BasicAuth()
-		if !ok || username != dummyUser.Username || password != dummyUser.Password {
-			c.JSON(http.StatusUnauthorized, gin.H{"status": "unauthorized"})
-			c.Abort()
+		key, hasKey := bearerAPIKey(c)
+		switch {
+		case ok:
+			user, ok = authenticate(username, password)
+		case hasKey:
+			user, ok = authenticateAPIKey(key)
+		case !required:
+			c.Next()
+			return
+		}
+		if !ok {
+			abortUnauthenticated(c)
+			return
+		}
+		if !user.Active {
+			abortForbidden(c, "account_suspended", "account suspended")
+			return
+		}
+		setCurrentUser(c, user)
+		c.Next()
+	}
+}
+
+// Middleware that only lets through users with the given role: 401 when
+// nobody authenticated, 403 when the user lacks the role
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := currentUser(c)
+		if !ok {
+			abortUnauthenticated(c)
+			return
+		}
+		if user.Role != role {
+			abortForbidden(c, "insufficient_role", "insufficient role")
 			return
 		}
 		c.Next()
 	}
 }
 
-// Function to check if a user exists
-func userExists(username string) bool {
+// Look up a user by username and password
+func authenticate(username, password string) (User, bool) {
+	if strings.EqualFold(username, dummyUser.Username) && password == dummyUser.Password {
+		return dummyUser, true
+	}
+	usersMu.RLock()
+	defer usersMu.RUnlock()
+	for _, user := range users {
+		if strings.EqualFold(user.Username, username) && user.Password == password {
+			return user, true
+		}
+	}
+	return User{}, false
+}
+
+// Function to check if a username is taken by a user other than exceptID,
+// ignoring case; the caller holds usersMu
+func userExists(username string, exceptID int) bool {
+	if strings.EqualFold(dummyUser.Username, username) {
+		return true
+	}
 	for _, user := range users {
-		if user.Username == username {
+		if user.ID != exceptID && strings.EqualFold(user.Username, username) {
 			return true
 		}
 	}
 	return false
 }
 
+// Function to find a user by ID
+func findUserByID(id int) (User, bool) {
+	if dummyUser.ID == id {
+		return dummyUser, true
+	}
+	usersMu.RLock()
+	defer usersMu.RUnlock()
+	for _, user := range users {
+		if user.ID == id {
+			return user, true
+		}
+	}
+	return User{}, false
+}
+
+// Function to find a user by username, ignoring case
+func findUserByUsername(username string) (User, bool) {
+	if strings.EqualFold(dummyUser.Username, username) {
+		return dummyUser, true
+	}
+	usersMu.RLock()
+	defer usersMu.RUnlock()
+	for _, user := range users {
+		if strings.EqualFold(user.Username, username) {
+			return user, true
+		}
+	}
+	return User{}, false
+}
+
+// Function to count the users with the admin role; the caller holds usersMu
+func countAdmins() int {
+	count := 0
+	if dummyUser.Role == roleAdmin {
+		count++
+	}
+	for _, user := range users {
+		if user.Role == roleAdmin {
+			count++
+		}
+	}
+	return count
+}
+
 // Validate user input
 func validateUserInput(user User) bool {
 	return user.Username != "" && user.Email != "" && user.Password != ""
 }
 
+// Routes frequent enough that logging them would only be noise
+var unloggedPaths = []string{"/ping"}
+
 // Logging middleware to log requests
 func LoggerMiddleware() gin.HandlerFunc {
+	skip := map[string]bool{}
+	for _, path := range unloggedPaths {
+		skip[path] = true
+	}
 	return func(c *gin.Context) {
+		if skip[c.FullPath()] {
+			c.Next()
+			return
+		}
 		t := time.Now()
+		c.Set("request_start", t)
 		c.Next()
 		latency := time.Since(t)
-		log.Printf("%s %s %s in %v", c.Request.Method, c.Request.URL.Path, c.ClientIP(), latency)
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"ip", c.ClientIP(),
+			"latency", latency,
+			"request_id", c.GetString("request_id"),
+		}
+		// Each request is logged once. Successful requests may be sampled;
+		// errors and slow requests never are.
+		switch {
+		case latency > slowRequestThreshold:
+			logger.Warn("slow request", attrs...)
+		case c.Writer.Status() >= 400 || rand.Float64() < logSampleRate:
+			logger.Info("request", attrs...)
+		}
 	}
 }
 
-// Main function that sets up the Gin server
-func hew() {
-	router := gin.Default()
+// Configure which proxies may set X-Forwarded-For from the TRUSTED_PROXIES
+// environment variable (comma separated IPs or CIDRs). When the list is empty
+// no proxy is trusted and c.ClientIP() always uses the connection's remote address.
+func configureTrustedProxies(router *gin.Engine) {
+	if err := router.SetTrustedProxies(splitList(os.Getenv("TRUSTED_PROXIES"))); err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+}
 
-	// Use middleware for logging and authentication
+// Set up the Gin engine with middleware and all API routes
+func setupRouter() *gin.Engine {
+	router := gin.New()
+	router.Use(gin.Recovery())
+	configureTrustedProxies(router)
+
+	// Use middleware for logging and authentication. Each group gets its own
+	// CORS policy, which runs first so preflight requests skip authentication.
+	router.Use(RequestIDMiddleware())
 	router.Use(LoggerMiddleware())
-	auth := router.Group("/", AuthMiddleware())
+	if responseTimeHeader {
+		router.Use(ResponseTimeMiddleware())
+	}
+	router.Use(CacheControlMiddleware())
+	router.Use(ReadOnlyMiddleware())
+	router.Use(BodyLimitMiddleware(maxBodyBytes))
+	router.Use(BodyLoggerMiddleware())
+	router.Use(PrettyJSONMiddleware())
+	public := router.Group("/", CORSMiddleware(publicCORS), QuotaMiddleware(), TimeoutMiddleware(publicTimeout))
+	auth := router.Group("/", CORSMiddleware(authCORS), AuthMiddleware(), QuotaMiddleware(), TimeoutMiddleware(authTimeout))
+	admin := auth.Group("/", RequireRole(roleAdmin), IPFilterMiddleware(adminIPAllow, adminIPDeny))
+	// Public routes that don't count towards quotas
+	unmetered := router.Group("/", CORSMiddleware(publicCORS), TimeoutMiddleware(publicTimeout))
+	expensive := ConcurrencyLimitMiddleware(maxConcurrentExpensive, expensiveQueue)
+
+	// Service Routes. /ping sits outside the groups so probes skip CORS,
+	// authentication and quotas.
+	router.GET("/ping", ping)
+	public.GET("/healthz", healthCheck)
+	public.GET("/info", info)
+	unmetered.GET("/time", serverTime)
+	public.GET("/openapi.json", openAPIHandler(router))
+
+	// Feed Routes
+	feeds := public.Group("/", FeatureMiddleware("feeds"))
+	feeds.GET("/feed.xml", getRSSFeed)
+	feeds.GET("/atom.xml", getAtomFeed)
 
 	// User Routes
-	router.GET("/users", getUsers)
-	router.POST("/users", createUser)
-	router.PUT("/users/:id", updateUser)
-	router.DELETE("/users/:id", deleteUser)
+	public.GET("/users", OptionalAuthMiddleware(), getUsers)
+	public.POST("/users", EndpointMiddleware("registration", "Registration is currently closed"), SchemaMiddleware("user"), createUser)
+	public.POST("/users/batch", getUsersBatch)
+	public.GET("/users/:id", OptionalAuthMiddleware(), getUserProfile)
+	public.GET("/users/:id/stats", getUserStats)
+	public.GET("/users/:id/posts/count", OptionalAuthMiddleware(), getUserPostCounts)
+	auth.PUT("/users/:id", SchemaMiddleware("user"), updateUser)
+	auth.DELETE("/users/:id", deleteUser)
+	admin.PUT("/users/:id/role", updateUserRole)
+	admin.POST("/users/:id/suspend", suspendUser)
+	admin.POST("/users/:id/unsuspend", unsuspendUser)
+
+	auth.GET("/whoami", whoami)
+	auth.GET("/search", expensive, search)
+	auth.GET("/users/:id/activity", getUserActivity)
+	auth.GET("/users/me/posts", getMyPosts)
+	auth.GET("/users/me/api-keys", getAPIKeys)
+	auth.POST("/users/me/api-keys", createAPIKey)
+	auth.DELETE("/users/me/api-keys/:id", deleteAPIKey)
+	auth.GET("/users/me/notifications", getNotifications)
+	auth.POST("/users/me/notifications/read-all", markNotificationsRead)
+	auth.POST("/users/:id/follow", followUser)
+	auth.DELETE("/users/:id/follow", unfollowUser)
+	auth.GET("/users/:id/followers", getFollowers)
+	auth.GET("/users/:id/following", getFollowing)
 
 	// Post Routes
 	auth.GET("/posts", getPosts)
-	auth.POST("/posts", createPost)
-	auth.PUT("/posts/:id", updatePost)
+	auth.GET("/posts/feed", getFeed)
+	auth.GET("/feed", getFollowingFeed)
+	auth.GET("/posts/archive", getPostArchive)
+	auth.POST("/posts", SchemaMiddleware("post"), createPost)
+	auth.POST("/posts/preview", expensive, previewPost)
+	auth.POST("/posts/import", EndpointMiddleware("post_import", "Post import is currently disabled"), expensive, importPosts)
+	auth.GET("/posts/:id", getPostByID)
+	auth.PUT("/posts/:id", SchemaMiddleware("post"), updatePost)
+	auth.PATCH("/posts/:id", patchPost)
 	auth.DELETE("/posts/:id", deletePost)
+	auth.POST("/posts/:id/duplicate", duplicatePost)
+	auth.POST("/posts/:id/restore", restorePost)
+	admin.POST("/posts/:id/pin", pinPost)
+	admin.DELETE("/posts/:id/pin", unpinPost)
+	auth.GET("/posts/:id/history", getPostHistory)
+	auth.GET("/posts/:id/similar-by-content", getSimilarPosts)
+	auth.POST("/posts/:id/revert/:revisionID", revertPost)
+
+	// Moderation Routes
+	auth.POST("/posts/:id/report", reportPost)
+	admin.GET("/reports", getReports)
+	admin.POST("/reports/:id/resolve", resolveReport)
+
+	// Admin Routes
+	admin.GET("/admin/activity", getAdminActivity)
+	admin.GET("/admin/users/email-domains", getEmailDomains)
+	admin.GET("/admin/binding-failures", getBindFailures)
+	admin.GET("/admin/webhooks", getWebhooks)
+	admin.POST("/admin/webhooks", createWebhook)
+	admin.DELETE("/admin/webhooks/:id", deleteWebhook)
+	admin.GET("/admin/features", getFeatures)
+	admin.PUT("/admin/features/:name", setFeature)
+	admin.GET("/admin/read-only", getReadOnly)
+	admin.PUT("/admin/read-only", setReadOnly)
+	admin.POST("/admin/drain", startDrain)
 
-	// Start the server
-	router.Run(":8080")
+	// CORS preflight routes, answered by each group's CORS middleware
+	preflight(unmetered, "/time")
+	preflight(public, "/openapi.json", "/users", "/users/batch", "/users/:id/stats", "/users/:id/posts/count")
+	preflight(auth, "/whoami", "/search", "/users/:id", "/users/:id/activity", "/users/me/posts", "/users/me/api-keys", "/users/me/api-keys/:id",
+		"/users/me/notifications", "/users/me/notifications/read-all",
+		"/users/:id/follow", "/users/:id/followers", "/users/:id/following", "/users/:id/role", "/users/:id/suspend", "/users/:id/unsuspend",
+		"/posts", "/posts/feed", "/feed", "/posts/archive", "/posts/preview", "/posts/import", "/posts/:id", "/posts/:id/duplicate",
+		"/posts/:id/restore", "/posts/:id/pin", "/posts/:id/history", "/posts/:id/similar-by-content", "/posts/:id/revert/:revisionID", "/posts/:id/report", "/reports", "/reports/:id/resolve",
+		"/admin/activity", "/admin/users/email-domains", "/admin/binding-failures", "/admin/webhooks", "/admin/webhooks/:id",
+		"/admin/features", "/admin/features/:name", "/admin/read-only", "/admin/drain")
+
+	return router
 }
 
 // Get all users
 func getUsers(c *gin.Context) {
-	if len(users) == 0 {
+	var query struct {
+		Pagination
+		Sorting
+	}
+	if !bindQuery(c, &query) {
+		return
+	}
+	usersMu.RLock()
+	snapshot := make([]User, len(users))
+	copy(snapshot, users)
+	usersMu.RUnlock()
+	if len(snapshot) == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"message": "No users found"})
 		return
 	}
-	c.JSON(http.StatusOK, users)
+	if !applySort(c, snapshot, userSortFields, query.Sort, usersDefaultSort) {
+		return
+	}
+	start, end := paginate(c, len(snapshot), query.Page, query.Limit)
+	result := make([]PublicUser, 0, end-start)
+	for _, user := range snapshot[start:end] {
+		result = append(result, toPublicUser(c, user))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// Get several users by ID in one call, keyed by ID; unknown IDs are skipped
+func getUsersBatch(c *gin.Context) {
+	var body struct {
+		IDs []int `json:"ids" binding:"required"`
+	}
+	if err := bindJSON(c, &body); err != nil {
+		handleBindError(c, err)
+		return
+	}
+	if len(body.IDs) > maxBatchIDs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d ids per request", maxBatchIDs)})
+		return
+	}
+	wanted := make(map[int]bool, len(body.IDs))
+	for _, id := range body.IDs {
+		wanted[id] = true
+	}
+	result := map[int]PublicUser{}
+	if wanted[dummyUser.ID] {
+		result[dummyUser.ID] = toPublicUser(c, dummyUser)
+	}
+	usersMu.RLock()
+	for _, user := range users {
+		if wanted[user.ID] {
+			result[user.ID] = toPublicUser(c, user)
+		}
+	}
+	usersMu.RUnlock()
+	c.JSON(http.StatusOK, result)
 }
 
 // Create a new user
 func createUser(c *gin.Context) {
 	var newUser User
-	if err := c.BindJSON(&newUser); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &newUser); err != nil {
+		handleBindError(c, err)
 		return
 	}
 	if !validateUserInput(newUser) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user input"})
 		return
 	}
-	if userExists(newUser.Username) {
+	if !checkUsername(c, newUser.Username) {
+		return
+	}
+	usersMu.Lock()
+	defer usersMu.Unlock()
+	if userExists(newUser.Username, 0) {
 		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
 		return
 	}
-	newUser.ID = len(users) + 1
+	newUser.ID = nextID(&userIDSeq)
+	newUser.Role = defaultUserRole
+	if firstUserAdmin && !firstUserClaimed {
+		firstUserClaimed = true
+		newUser.Role = roleAdmin
+		logger.Info("first user made admin", "user_id", newUser.ID, "username", newUser.Username)
+	}
+	newUser.Active = true
 	newUser.Created = time.Now()
 	users = append(users, newUser)
-	c.JSON(http.StatusCreated, newUser)
+	c.Header("Location", fmt.Sprintf("/users/%v", newUser.ID))
+	c.Header("ETag", computeETag(userVersion(newUser)))
+	c.JSON(http.StatusCreated, toPublicUser(c, newUser))
 }
 
-// Update an existing user
+// Update an existing user; only the user themselves and admins may
 func updateUser(c *gin.Context) {
+	var updatedUser User
+	if err := bindJSON(c, &updatedUser); err != nil {
+		handleBindError(c, err)
+		return
+	}
+	if !checkUsername(c, updatedUser.Username) {
+		return
+	}
 	id := c.Param("id")
+	usersMu.Lock()
+	defer usersMu.Unlock()
 	for i, user := range users {
 		if fmt.Sprintf("%d", user.ID) == id {
-			var updatedUser User
-			if err := c.BindJSON(&updatedUser); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			if !canManageUser(c, user.ID) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed to manage this user"})
+				return
+			}
+			if !checkIfMatch(c, userVersion(users[i])) {
+				return
+			}
+			if userExists(updatedUser.Username, user.ID) {
+				c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
 				return
 			}
 			users[i].Username = updatedUser.Username
 			users[i].Email = updatedUser.Email
 			users[i].Password = updatedUser.Password
-			c.JSON(http.StatusOK, users[i])
+			c.Header("ETag", computeETag(userVersion(users[i])))
+			c.JSON(http.StatusOK, toPublicUser(c, users[i]))
 			return
 		}
 	}
 	c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
 }
 
-// Delete an existing user
+// Change the role of an existing user
+func updateUserRole(c *gin.Context) {
+	var body struct {
+		Role string `json:"role"`
+	}
+	if err := bindJSON(c, &body); err != nil {
+		handleBindError(c, err)
+		return
+	}
+	if body.Role != roleAdmin && body.Role != roleUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		return
+	}
+	id := c.Param("id")
+	usersMu.Lock()
+	defer usersMu.Unlock()
+	for i, user := range users {
+		if fmt.Sprintf("%d", user.ID) == id {
+			if user.Role == roleAdmin && body.Role != roleAdmin && countAdmins() <= 1 {
+				c.JSON(http.StatusConflict, gin.H{"error": "Cannot demote the last admin"})
+				return
+			}
+			users[i].Role = body.Role
+			recordAudit(c, "user.role_changed", "user", user.ID, user.Role+" -> "+body.Role)
+			c.JSON(http.StatusOK, toPublicUser(c, users[i]))
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+}
+
+// Suspend a user so they can no longer authenticate
+func suspendUser(c *gin.Context) {
+	setUserActive(c, false)
+}
+
+// Lift the suspension of a user
+func unsuspendUser(c *gin.Context) {
+	setUserActive(c, true)
+}
+
+// Helper function to change whether a user is active and audit it
+func setUserActive(c *gin.Context, active bool) {
+	id := c.Param("id")
+	usersMu.Lock()
+	defer usersMu.Unlock()
+	for i, user := range users {
+		if fmt.Sprintf("%d", user.ID) == id {
+			users[i].Active = active
+			action := "user.suspended"
+			if active {
+				action = "user.unsuspended"
+			}
+			recordAudit(c, action, "user", user.ID, "")
+			c.JSON(http.StatusOK, toPublicUser(c, users[i]))
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+}
+
+// Delete an existing user; only the user themselves and admins may
 func deleteUser(c *gin.Context) {
 	id := c.Param("id")
+	usersMu.Lock()
+	defer usersMu.Unlock()
 	for i, user := range users {
 		if fmt.Sprintf("%d", user.ID) == id {
+			if !canManageUser(c, user.ID) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed to manage this user"})
+				return
+			}
 			users = append(users[:i], users[i+1:]...)
+			removeFollows(user.ID)
+			removeAPIKeys(user.ID)
+			removeNotifications(user.ID)
 			c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
 			return
 		}
@@ -164,40 +643,363 @@ func deleteUser(c *gin.Context) {
 	c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
 }
 
-// Get all posts
+// Count users per email domain, the built-in admin included as in search and
+// batch lookups. Deleted users are already gone from the store; addresses
+// without a usable domain are left out.
+func getEmailDomains(c *gin.Context) {
+	counts := map[string]int{}
+	if domain := emailDomain(dummyUser.Email); domain != "" {
+		counts[domain]++
+	}
+	usersMu.RLock()
+	for _, user := range users {
+		if domain := emailDomain(user.Email); domain != "" {
+			counts[domain]++
+		}
+	}
+	usersMu.RUnlock()
+	c.JSON(http.StatusOK, counts)
+}
+
+// Helper function to get the lower-cased domain of an email address, or ""
+// when it has none
+func emailDomain(email string) string {
+	if addr, err := mail.ParseAddress(email); err == nil {
+		email = addr.Address
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(email[at+1:])), ".")
+}
+
+// Return the authenticated user straight from the request context
+func whoami(c *gin.Context) {
+	user := mustAuth(c)
+	if user == nil {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"role":     user.Role,
+	})
+}
+
+// PostListItem is a post as shown in lists: an excerpt, and the full content
+// only when asked for with ?full=true
+type PostListItem struct {
+	Post
+	Content string `json:"content,omitempty"`
+	Excerpt string `json:"excerpt"`
+}
+
+// Get all posts. The q, tag, author, from/to (creation dates, inclusive) and
+// status filters can be combined and must all match. tag and author may be
+// repeated: a post must carry every tag given, and be written by any one of
+// the authors given. Posts that aren't published are only listed for the
+// users allowed to manage them.
 func getPosts(c *gin.Context) {
-	if len(posts) == 0 {
+	var query struct {
+		Pagination
+		Sorting
+		Q              string    `form:"q"`
+		Tags           []string  `form:"tag"`
+		Authors        []string  `form:"author"`
+		From           time.Time `form:"from" time_format:"2006-01-02"`
+		To             time.Time `form:"to" time_format:"2006-01-02"`
+		Status         string    `form:"status" binding:"omitempty,oneof=draft published scheduled"`
+		IncludeDeleted bool      `form:"include_deleted"`
+		Full           bool      `form:"full"`
+	}
+	if !bindQuery(c, &query) {
+		return
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.From.After(query.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+	status := query.Status
+	if status == "" {
+		status = postStatusPublished
+	}
+	q := strings.ToLower(query.Q)
+	authorIDs := map[int]bool{}
+	for _, author := range query.Authors {
+		user, ok := findUserByUsername(author)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+			return
+		}
+		authorIDs[user.ID] = true
+	}
+	includeDeleted := query.IncludeDeleted && isAdmin(c)
+	result := []Post{}
+	postsMu.RLock()
+	for _, post := range posts {
+		if post.DeletedAt != nil && !includeDeleted {
+			continue
+		}
+		if post.Status != status || !canReadPost(c, post) {
+			continue
+		}
+		if len(authorIDs) > 0 && !authorIDs[post.UserID] {
+			continue
+		}
+		if !hasAllTags(post, query.Tags) {
+			continue
+		}
+		if !query.From.IsZero() && post.Created.Before(query.From) {
+			continue
+		}
+		if !query.To.IsZero() && !post.Created.Before(query.To.AddDate(0, 0, 1)) {
+			continue
+		}
+		if q != "" && !strings.Contains(strings.ToLower(post.Title), q) && !strings.Contains(strings.ToLower(post.Content), q) {
+			continue
+		}
+		result = append(result, post)
+	}
+	postsMu.RUnlock()
+	if len(result) == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"message": "No posts found"})
 		return
 	}
-	c.JSON(http.StatusOK, posts)
+	if !applySort(c, result, postSortFields, query.Sort, postsDefaultSort) {
+		return
+	}
+	pinnedFirst(result)
+	start, end := paginate(c, len(result), query.Page, query.Limit)
+	items := make([]PostListItem, 0, end-start)
+	for _, post := range result[start:end] {
+		item := PostListItem{Post: post, Excerpt: excerpt(post.Content, postExcerptLength)}
+		if query.Full {
+			item.Content = post.Content
+		}
+		items = append(items, item)
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// Get the authenticated user's own posts, including drafts and scheduled ones
+func getMyPosts(c *gin.Context) {
+	var query struct {
+		Pagination
+		Sorting
+		Status string `form:"status" binding:"omitempty,oneof=draft published scheduled"`
+	}
+	if !bindQuery(c, &query) {
+		return
+	}
+	user := mustAuth(c)
+	if user == nil {
+		return
+	}
+	result := []Post{}
+	postsMu.RLock()
+	for _, post := range posts {
+		if post.UserID != user.ID || post.DeletedAt != nil {
+			continue
+		}
+		if query.Status != "" && post.Status != query.Status {
+			continue
+		}
+		result = append(result, post)
+	}
+	postsMu.RUnlock()
+	if !applySort(c, result, postSortFields, query.Sort, postsDefaultSort) {
+		return
+	}
+	start, end := paginate(c, len(result), query.Page, query.Limit)
+	c.JSON(http.StatusOK, result[start:end])
 }
 
 // Create a new post
 func createPost(c *gin.Context) {
+	var query struct {
+		Force bool `form:"force"`
+	}
+	if !bindQuery(c, &query) {
+		return
+	}
 	var newPost Post
-	if err := c.BindJSON(&newPost); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &newPost); err != nil {
+		handleBindError(c, err)
+		return
+	}
+	switch newPost.Status {
+	case "":
+		newPost.Status = postStatusPublished
+	case postStatusDraft, postStatusPublished, postStatusScheduled:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post status"})
+		return
+	}
+	if !validatePostInput(newPost) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post input"})
+		return
+	}
+	if !checkContentLength(c, newPost.Content) || !checkTags(c, newPost.Tags) {
+		return
+	}
+	newPost.Content = storedContent(newPost.Content)
+	newPost.Likes = 0
+	newPost.Views = 0
+	newPost.Pinned = false
+	newPost.Updated = nil
+	user := mustAuth(c)
+	if user == nil {
+		return
+	}
+	newPost.UserID = user.ID
+	postsMu.Lock()
+	defer postsMu.Unlock()
+	if user.Role != roleAdmin && countUserPosts(newPost.UserID) >= maxPostsPerUser {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("Post limit of %d reached", maxPostsPerUser)})
 		return
 	}
-	newPost.ID = len(posts) + 1
+	if !query.Force {
+		if existing := findRecentDuplicate(newPost); existing != nil {
+			c.Header("Location", fmt.Sprintf("/posts/%v", existing.ID))
+			c.JSON(http.StatusConflict, gin.H{"error": "Duplicate post", "post_id": existing.ID})
+			return
+		}
+	}
+	newPost.ID = nextID(&postIDSeq)
 	newPost.Created = time.Now()
 	posts = append(posts, newPost)
+	dispatchEvent(eventPostCreated, newPost)
+	if newPost.Status == postStatusPublished {
+		dispatchEvent(eventPostPublished, newPost)
+	}
+	c.Header("Location", fmt.Sprintf("/posts/%v", newPost.ID))
+	c.Header("ETag", computeETag(newPost))
 	c.JSON(http.StatusCreated, newPost)
 }
 
-// Update an existing post
+// Update an existing post; only its author and admins may
 func updatePost(c *gin.Context) {
+	var updatedPost Post
+	if err := bindJSON(c, &updatedPost); err != nil {
+		handleBindError(c, err)
+		return
+	}
+	if !validatePostInput(updatedPost) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post input"})
+		return
+	}
+	if !checkContentLength(c, updatedPost.Content) || !checkTags(c, updatedPost.Tags) {
+		return
+	}
+	postsMu.Lock()
+	defer postsMu.Unlock()
+	i, ok := findOwnedPost(c)
+	if !ok || !checkIfMatch(c, posts[i]) {
+		return
+	}
+	before := posts[i]
+	posts[i].Title = updatedPost.Title
+	posts[i].Content = storedContent(updatedPost.Content)
+	posts[i].Tags = updatedPost.Tags
+	now := time.Now()
+	posts[i].Updated = &now
+	editorID, _ := currentUserID(c)
+	recordRevision(before, posts[i], editorID)
+	c.Header("ETag", computeETag(posts[i]))
+	c.JSON(http.StatusOK, posts[i])
+}
+
+// Partially update a post: only the fields present in the body change, and
+// each of them is validated the same way as on create
+func patchPost(c *gin.Context) {
+	var patch struct {
+		Title   *string   `json:"title" normalize:"collapse"`
+		Content *string   `json:"content"`
+		Tags    *[]string `json:"tags"`
+		Status  *string   `json:"status"`
+	}
+	if err := bindJSON(c, &patch); err != nil {
+		handleBindError(c, err)
+		return
+	}
+	if (patch.Title != nil && *patch.Title == "") || (patch.Content != nil && *patch.Content == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post input"})
+		return
+	}
+	if patch.Status != nil {
+		switch *patch.Status {
+		case postStatusDraft, postStatusPublished, postStatusScheduled:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post status"})
+			return
+		}
+	}
+	if patch.Content != nil && !checkContentLength(c, *patch.Content) {
+		return
+	}
+	if patch.Tags != nil && !checkTags(c, *patch.Tags) {
+		return
+	}
+	postsMu.Lock()
+	defer postsMu.Unlock()
+	i, ok := findOwnedPost(c)
+	if !ok || !checkIfMatch(c, posts[i]) {
+		return
+	}
+	before := posts[i]
+	if patch.Title != nil {
+		posts[i].Title = *patch.Title
+	}
+	if patch.Content != nil {
+		posts[i].Content = storedContent(*patch.Content)
+	}
+	if patch.Tags != nil {
+		posts[i].Tags = *patch.Tags
+	}
+	if patch.Status != nil {
+		posts[i].Status = *patch.Status
+	}
+	now := time.Now()
+	posts[i].Updated = &now
+	editorID, _ := currentUserID(c)
+	recordRevision(before, posts[i], editorID)
+	if before.Status != postStatusPublished && posts[i].Status == postStatusPublished {
+		dispatchEvent(eventPostPublished, posts[i])
+	}
+	c.Header("ETag", computeETag(posts[i]))
+	c.JSON(http.StatusOK, posts[i])
+}
+
+// Soft-delete an existing post; only its author and admins may
+func deletePost(c *gin.Context) {
+	postsMu.Lock()
+	defer postsMu.Unlock()
+	i, ok := findOwnedPost(c)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	posts[i].DeletedAt = &now
+	c.JSON(http.StatusOK, gin.H{"message": "Post deleted"})
+}
+
+// Restore a soft-deleted post, allowed for its author and admins
+func restorePost(c *gin.Context) {
 	id := c.Param("id")
+	postsMu.Lock()
+	defer postsMu.Unlock()
 	for i, post := range posts {
 		if fmt.Sprintf("%d", post.ID) == id {
-			var updatedPost Post
-			if err := c.BindJSON(&updatedPost); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			if !canManagePost(c, post) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed to restore this post"})
+				return
+			}
+			if post.DeletedAt == nil {
+				c.JSON(http.StatusConflict, gin.H{"error": "Post is not deleted"})
 				return
 			}
-			posts[i].Title = updatedPost.Title
-			posts[i].Content = updatedPost.Content
+			posts[i].DeletedAt = nil
 			c.JSON(http.StatusOK, posts[i])
 			return
 		}
@@ -205,17 +1007,101 @@ func updatePost(c *gin.Context) {
 	c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
 }
 
-// Delete an existing post
-func deletePost(c *gin.Context) {
-	id := c.Param("id")
-	for i, post := range posts {
-		if fmt.Sprintf("%d", post.ID) == id {
-			posts = append(posts[:i], posts[i+1:]...)
-			c.JSON(http.StatusOK, gin.H{"message": "Post deleted"})
-			return
+// Create a draft copy of an existing post owned by the authenticated user
+func duplicatePost(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
+		return
+	}
+	postsMu.Lock()
+	defer postsMu.Unlock()
+	source := findPostByID(id, false)
+	if source == nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
+		return
+	}
+	if !canReadPost(c, *source) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed to read this post"})
+		return
+	}
+	user := mustAuth(c)
+	if user == nil {
+		return
+	}
+	if user.Role != roleAdmin && countUserPosts(user.ID) >= maxPostsPerUser {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("Post limit of %d reached", maxPostsPerUser)})
+		return
+	}
+	newPost := Post{
+		ID:      nextID(&postIDSeq),
+		Title:   source.Title + " (copy)",
+		Content: source.Content,
+		Tags:    source.Tags,
+		UserID:  user.ID,
+		Status:  postStatusDraft,
+		Created: time.Now(),
+	}
+	posts = append(posts, newPost)
+	dispatchEvent(eventPostCreated, newPost)
+	c.Header("Location", fmt.Sprintf("/posts/%v", newPost.ID))
+	c.JSON(http.StatusCreated, newPost)
+}
+
+// PostAuthor is the public view of a post's author embedded by ?expand=author
+type PostAuthor struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Deleted  bool   `json:"deleted,omitempty"`
+}
+
+// Get a single post. ?expand=author embeds the author's public fields, with a
+// "deleted user" placeholder when the author no longer exists.
+func getPostByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
+		return
+	}
+	postsMu.RLock()
+	post := findPostByID(id, false)
+	postsMu.RUnlock()
+	if post == nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
+		return
+	}
+	if !canReadPost(c, *post) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed to read this post"})
+		return
+	}
+	// The ETag covers the representation actually sent, so an expanded
+	// response never shares a validator with the plain post.
+	var body interface{} = post
+	if slices.Contains(queryList(c, "expand"), "author") {
+		author := PostAuthor{ID: post.UserID, Username: "deleted user", Deleted: true}
+		if user, ok := findUserByID(post.UserID); ok {
+			author = PostAuthor{ID: user.ID, Username: user.Username}
 		}
+		body = struct {
+			*Post
+			Author PostAuthor `json:"author"`
+		}{post, author}
 	}
-	c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
+	etag := computeETag(body)
+	c.Header("ETag", etag)
+	if checkIfNoneMatch(c, etag) {
+		return
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// Helper function to check whether the authenticated user may read a post.
+// Published posts are public, anything else only to its author and admins.
+func canReadPost(c *gin.Context, post Post) bool {
+	if post.Status == postStatusPublished {
+		return true
+	}
+	return canManagePost(c, post)
 }
 
 // Helper function to validate post input
@@ -223,10 +1109,35 @@ func validatePostInput(post Post) bool {
 	return post.Title != "" && post.Content != ""
 }
 
-// Helper function to find a post by ID
-func findPostByID(id int) *Post {
+// Helper function to count the live posts owned by a user; the caller holds postsMu
+func countUserPosts(userID int) int {
+	count := 0
+	for _, post := range posts {
+		if post.UserID == userID && post.DeletedAt == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Helper function to find a live post by the same author with the same title
+// and content created within duplicatePostWindow; the caller holds postsMu
+func findRecentDuplicate(post Post) *Post {
+	since := time.Now().Add(-duplicatePostWindow)
+	for _, existing := range posts {
+		if existing.UserID == post.UserID && existing.DeletedAt == nil && existing.Created.After(since) &&
+			existing.Title == post.Title && existing.Content == post.Content {
+			return &existing
+		}
+	}
+	return nil
+}
+
+// Helper function to find a post by ID, skipping soft-deleted posts unless
+// includeDeleted is set; the caller holds postsMu
+func findPostByID(id int, includeDeleted bool) *Post {
 	for _, post := range posts {
-		if post.ID == id {
+		if post.ID == id && (includeDeleted || post.DeletedAt == nil) {
 			return &post
 		}
 	}
@@ -237,24 +1148,23 @@ func findPostByID(id int) *Post {
 func logRequest(level string, message string) {
 	switch level {
 	case "INFO":
-		log.Printf("[INFO] %s", message)
+		logger.Info(message)
 	case "ERROR":
-		log.Printf("[ERROR] %s", message)
+		logger.Error(message)
 	default:
-		log.Printf("[DEBUG] %s", message)
+		logger.Debug(message)
 	}
 }
 
-// Function to simulate complex business logic
-func complexBusinessLogic(data string) string {
+// Function to simulate complex business logic, giving up when ctx is done
+func complexBusinessLogic(ctx context.Context, data string) (string, error) {
 	// Simulate heavy computation or logic
-	time.Sleep(2 * time.Second)
-	return fmt.Sprintf("Processed: %s", data)
-}
-
-// Error handler for JSON binding errors
-func handleBindError(c *gin.Context, err error) {
-	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	select {
+	case <-time.After(2 * time.Second):
+		return fmt.Sprintf("Processed: %s", data), nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
 }
 
 // Mock function for database transaction simulation
@@ -265,7 +1175,16 @@ func simulateTransaction() error {
 	return nil
 }
 
+// Trivial liveness probe for load balancers
+func ping(c *gin.Context) {
+	c.String(http.StatusOK, "pong")
+}
+
 // Health check route
 func healthCheck(c *gin.Context) {
+	if draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 }
@@ -0,0 +1,686 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPostOwnershipIsEnforced(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	createTestUser(t, r, "alice", "pw-alice")
+	createTestUser(t, r, "bob", "pw-bob")
+	post := createTestPost(t, r, `{"title":"Mine","content":"alice's post"}`, "alice", "pw-alice")
+	path := "/posts/" + strconv.Itoa(post.ID)
+
+	tests := []struct {
+		name         string
+		method, body string
+		user, pass   string
+		want         int
+	}{
+		{"other user cannot update", http.MethodPut, `{"title":"Hijacked","content":"x"}`, "bob", "pw-bob", http.StatusForbidden},
+		{"other user cannot patch", http.MethodPatch, `{"title":"Hijacked"}`, "bob", "pw-bob", http.StatusForbidden},
+		{"other user cannot delete", http.MethodDelete, "", "bob", "pw-bob", http.StatusForbidden},
+		{"author can update", http.MethodPut, `{"title":"Edited","content":"x"}`, "alice", "pw-alice", http.StatusOK},
+		{"admin can update", http.MethodPut, `{"title":"Moderated","content":"x"}`, dummyUser.Username, dummyUser.Password, http.StatusOK},
+		{"author can delete", http.MethodDelete, "", "alice", "pw-alice", http.StatusOK},
+		{"deleted post is gone", http.MethodDelete, "", "alice", "pw-alice", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(t, r, tt.method, path, tt.body, tt.user, tt.pass)
+			if w.Code != tt.want {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestUserIDsAreNeverReused(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	first := createTestUser(t, r, "alice", "pw")
+	second := createTestUser(t, r, "bob", "pw")
+	if w := request(t, r, http.MethodDelete, "/users/"+strconv.Itoa(second.ID), "", dummyUser.Username, dummyUser.Password); w.Code != http.StatusOK {
+		t.Fatalf("deleting user: %d %s", w.Code, w.Body.String())
+	}
+	third := createTestUser(t, r, "carol", "pw")
+	if third.ID <= second.ID || third.ID <= first.ID {
+		t.Fatalf("new user got ID %d, want more than %d and %d", third.ID, first.ID, second.ID)
+	}
+}
+
+func TestListingWhileDeletingIsRaceFree(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	created := make([]User, 0, 20)
+	for i := 0; i < 20; i++ {
+		created = append(created, createTestUser(t, r, "user"+strconv.Itoa(i), "pw"))
+		createTestPost(t, r, `{"title":"post `+strconv.Itoa(i)+`","content":"x"}`, dummyUser.Username, dummyUser.Password)
+	}
+	var wg sync.WaitGroup
+	for _, user := range created {
+		wg.Add(3)
+		go func(id int) {
+			defer wg.Done()
+			request(t, r, http.MethodDelete, "/users/"+strconv.Itoa(id), "", dummyUser.Username, dummyUser.Password)
+		}(user.ID)
+		go func(id int) {
+			defer wg.Done()
+			request(t, r, http.MethodDelete, "/posts/"+strconv.Itoa(id), "", dummyUser.Username, dummyUser.Password)
+		}(user.ID - dummyUser.ID)
+		go func() {
+			defer wg.Done()
+			for _, path := range []string{"/users?limit=5&page=2", "/posts?limit=5&page=2"} {
+				w := request(t, r, http.MethodGet, path, "", dummyUser.Username, dummyUser.Password)
+				if w.Code != http.StatusOK && w.Code != http.StatusNotFound {
+					t.Errorf("GET %s: %d %s", path, w.Code, w.Body.String())
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestUsernamesAreUniqueIgnoringCase(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	createTestUser(t, r, "Alice", "pw")
+	for _, name := range []string{"alice", "ALICE", "aLiCe"} {
+		w := request(t, r, http.MethodPost, "/users", `{"username":"`+name+`","email":"x@example.com","password":"pw"}`)
+		if w.Code != http.StatusConflict {
+			t.Errorf("registering %q: got %d %s, want 409", name, w.Code, w.Body.String())
+		}
+	}
+	// The built-in admin's name is reserved, which validation rejects first
+	w := request(t, r, http.MethodPost, "/users", `{"username":"Admin","email":"x@example.com","password":"pw"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("registering %q: got %d %s, want 400", "Admin", w.Code, w.Body.String())
+	}
+	w = request(t, r, http.MethodGet, "/whoami", "", "aLICE", "pw")
+	if w.Code != http.StatusOK {
+		t.Fatalf("login with other casing: %d %s", w.Code, w.Body.String())
+	}
+	var me struct {
+		Username string `json:"username"`
+	}
+	decodeBody(t, w, &me)
+	if me.Username != "Alice" {
+		t.Errorf("got username %q, want the original casing %q", me.Username, "Alice")
+	}
+}
+
+func TestDuplicatePostsAreRejectedUnlessForced(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	body := `{"title":"Twice","content":"same words"}`
+	admin := []string{dummyUser.Username, dummyUser.Password}
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"first post is created", "", http.StatusCreated},
+		{"double submission is rejected", "", http.StatusConflict},
+		{"force creates it anyway", "?force=true", http.StatusCreated},
+		{"malformed force flag", "?force=yes-please", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(t, r, http.MethodPost, "/posts"+tt.query, body, admin...)
+			if w.Code != tt.want {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidPostInputIsRejected(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	admin := []string{dummyUser.Username, dummyUser.Password}
+	post := createTestPost(t, r, `{"title":"Original","content":"kept"}`, dummyUser.Username, dummyUser.Password)
+	path := "/posts/" + strconv.Itoa(post.ID)
+
+	tests := []struct {
+		name, method, path, body string
+	}{
+		{"create with blank title", http.MethodPost, "/posts", `{"title":"   ","content":""}`},
+		{"create without content", http.MethodPost, "/posts", `{"title":"t","content":""}`},
+		{"update to blank title", http.MethodPut, path, `{"title":"  ","content":"changed"}`},
+		{"update to empty content", http.MethodPut, path, `{"title":"changed","content":""}`},
+		{"patch to blank title", http.MethodPatch, path, `{"title":" "}`},
+		{"patch to an unknown status", http.MethodPatch, path, `{"title":"changed","status":"gone"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(t, r, tt.method, tt.path, tt.body, admin...)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("got %d %s, want 400", w.Code, w.Body.String())
+			}
+		})
+	}
+	postsMu.RLock()
+	stored := *findPostByID(post.ID, false)
+	count := len(posts)
+	postsMu.RUnlock()
+	if stored.Title != "Original" || stored.Content != "kept" || stored.Updated != nil {
+		t.Errorf("post was changed by rejected updates: %+v", stored)
+	}
+	if count != 1 {
+		t.Errorf("rejected creates stored posts: %d posts", count)
+	}
+}
+
+func TestExpandedPostHasItsOwnETag(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	post := createTestPost(t, r, `{"title":"t","content":"c"}`, dummyUser.Username, dummyUser.Password)
+	path := "/posts/" + strconv.Itoa(post.ID)
+
+	plain := request(t, r, http.MethodGet, path, "", dummyUser.Username, dummyUser.Password).Header().Get("ETag")
+	expanded := request(t, r, http.MethodGet, path+"?expand=author", "", dummyUser.Username, dummyUser.Password).Header().Get("ETag")
+	if plain == "" || expanded == "" || plain == expanded {
+		t.Fatalf("plain ETag %q and expanded ETag %q must differ", plain, expanded)
+	}
+
+	get := func(path, etag string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("If-None-Match", etag)
+		req.SetBasicAuth(dummyUser.Username, dummyUser.Password)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := get(path+"?expand=author", plain); code != http.StatusOK {
+		t.Errorf("expanded request revalidated with plain ETag: got %d, want 200", code)
+	}
+	if code := get(path+"?expand=author", expanded); code != http.StatusNotModified {
+		t.Errorf("expanded request with expanded ETag: got %d, want 304", code)
+	}
+	if code := get(path, expanded); code != http.StatusOK {
+		t.Errorf("plain request revalidated with expanded ETag: got %d, want 200", code)
+	}
+}
+
+func TestUserEmailsAreOnlyShownToAdminsAndSelf(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	alice := createTestUser(t, r, "alice", "secret123")
+	createTestUser(t, r, "bob", "secret456")
+	profile := "/users/" + strconv.Itoa(alice.ID)
+
+	tests := []struct {
+		name, path string
+		auth       []string
+		wantEmail  bool
+	}{
+		{"anonymous list", "/users", nil, false},
+		{"user list", "/users", []string{"bob", "secret456"}, false},
+		{"admin list", "/users", []string{dummyUser.Username, dummyUser.Password}, true},
+		{"anonymous profile", profile, nil, false},
+		{"other user's profile", profile, []string{"bob", "secret456"}, false},
+		{"own profile", profile, []string{"alice", "secret123"}, true},
+		{"admin viewing profile", profile, []string{dummyUser.Username, dummyUser.Password}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(t, r, http.MethodGet, tt.path, "", tt.auth...)
+			if w.Code != http.StatusOK {
+				t.Fatalf("got %d %s, want 200", w.Code, w.Body.String())
+			}
+			body := w.Body.String()
+			if strings.Contains(body, "password") || strings.Contains(body, "secret123") {
+				t.Errorf("response leaks a password: %s", body)
+			}
+			if got := strings.Contains(body, "alice@example.com"); got != tt.wantEmail {
+				t.Errorf("email shown = %v, want %v: %s", got, tt.wantEmail, body)
+			}
+		})
+	}
+
+	if w := request(t, r, http.MethodGet, "/users?sort=email", "", "bob", "secret456"); w.Code != http.StatusForbidden {
+		t.Errorf("non-admin sort by email: got %d, want 403", w.Code)
+	}
+}
+
+func TestPostFiltersCombine(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	jane := createTestUser(t, r, "jane", "secret123")
+	bob := createTestUser(t, r, "bob", "secret456")
+	day := func(month time.Month, d int) time.Time { return time.Date(2026, month, d, 12, 0, 0, 0, time.UTC) }
+	postsMu.Lock()
+	for _, p := range []Post{
+		{Title: "Go tips", UserID: jane.ID, Tags: []string{"go", "web"}, Created: day(1, 10)},
+		{Title: "Rust notes", UserID: jane.ID, Tags: []string{"rust"}, Created: day(2, 10)},
+		{Title: "Go generics", UserID: bob.ID, Tags: []string{"go"}, Created: day(2, 15)},
+		{Title: "Go draft", UserID: bob.ID, Tags: []string{"go"}, Created: day(2, 20), Status: postStatusDraft},
+		{Title: "Web go", UserID: jane.ID, Tags: []string{"Web"}, Created: day(3, 1)},
+	} {
+		p.ID = nextID(&postIDSeq)
+		p.Content = "body"
+		if p.Status == "" {
+			p.Status = postStatusPublished
+		}
+		posts = append(posts, p)
+	}
+	postsMu.Unlock()
+
+	tests := []struct {
+		query string
+		want  []int // nil means 404
+	}{
+		{"q=go&author=jane", []int{5, 1}},
+		{"q=go&tag=go", []int{3, 1}},
+		{"tag=go&author=bob", []int{3}},
+		{"tag=go&author=bob&status=draft", []int{4}},
+		{"tag=go&from=2026-02-01&to=2026-02-28", []int{3}},
+		{"q=go&tag=web&author=jane&from=2026-01-01&to=2026-01-31", []int{1}},
+		{"tag=go&tag=web", []int{1}},
+		{"q=go&sort=created", []int{1, 3, 5}},
+		{"q=go&limit=2&page=2", []int{1}},
+		{"tag=rust&author=bob", nil},
+		{"q=go&from=2026-04-01", nil},
+		{"q=rust&tag=go", nil},
+		{"author=nobody", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := request(t, r, http.MethodGet, "/posts?"+tt.query, "", dummyUser.Username, dummyUser.Password)
+			if tt.want == nil {
+				if w.Code != http.StatusNotFound {
+					t.Fatalf("got %d %s, want 404", w.Code, w.Body.String())
+				}
+				return
+			}
+			if w.Code != http.StatusOK {
+				t.Fatalf("got %d %s, want 200", w.Code, w.Body.String())
+			}
+			var items []PostListItem
+			decodeBody(t, w, &items)
+			ids := []int{}
+			for _, item := range items {
+				ids = append(ids, item.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("got posts %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchPostUpdatesOnlyGivenFields(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	createTestUser(t, r, "jane", "secret123")
+	createTestUser(t, r, "bob", "secret456")
+	post := createTestPost(t, r, `{"title":"Title","content":"Content","tags":["go"],"status":"draft"}`, "jane", "secret123")
+	path := "/posts/" + strconv.Itoa(post.ID)
+	patch := func(body string, auth ...string) Post {
+		t.Helper()
+		w := request(t, r, http.MethodPatch, path, body, auth...)
+		if w.Code != http.StatusOK {
+			t.Fatalf("PATCH %s: got %d %s, want 200", body, w.Code, w.Body.String())
+		}
+		var got Post
+		decodeBody(t, w, &got)
+		return got
+	}
+
+	got := patch(`{"title":"  New   title "}`, "jane", "secret123")
+	if got.Title != "New title" || got.Content != "Content" || !slices.Equal(got.Tags, []string{"go"}) || got.Status != postStatusDraft {
+		t.Errorf("title patch changed other fields: %+v", got)
+	}
+	if got.Updated == nil {
+		t.Error("Updated was not refreshed")
+	}
+	got = patch(`{"tags":["rust","web"],"status":"published"}`, "jane", "secret123")
+	if got.Title != "New title" || got.Content != "Content" || !slices.Equal(got.Tags, []string{"rust", "web"}) || got.Status != postStatusPublished {
+		t.Errorf("tags and status patch changed other fields: %+v", got)
+	}
+
+	tests := []struct {
+		name, body string
+		auth       []string
+		want       int
+	}{
+		{"blank title", `{"title":"   "}`, []string{"jane", "secret123"}, http.StatusBadRequest},
+		{"empty content", `{"content":""}`, []string{"jane", "secret123"}, http.StatusBadRequest},
+		{"unknown status", `{"status":"archived"}`, []string{"jane", "secret123"}, http.StatusBadRequest},
+		{"too many tags", `{"tags":["a","b","c","d","e","f","g","h","i","j","k"]}`, []string{"jane", "secret123"}, http.StatusBadRequest},
+		{"content too long", `{"content":"` + strings.Repeat("x", maxPostContentLength+1) + `"}`, []string{"jane", "secret123"}, http.StatusUnprocessableEntity},
+		{"not the author", `{"title":"Hijacked"}`, []string{"bob", "secret456"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := request(t, r, http.MethodPatch, path, tt.body, tt.auth...); w.Code != tt.want {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body.String(), tt.want)
+			}
+		})
+	}
+	postsMu.RLock()
+	stored := *findPostByID(post.ID, false)
+	postsMu.RUnlock()
+	if stored.Title != "New title" || stored.Content != "Content" || !slices.Equal(stored.Tags, []string{"rust", "web"}) || stored.Status != postStatusPublished {
+		t.Errorf("rejected patches changed the post: %+v", stored)
+	}
+
+	patch(`{"title":"By admin"}`, dummyUser.Username, dummyUser.Password)
+}
+
+func TestOnlyTheFirstUserIsMadeAdmin(t *testing.T) {
+	resetStores(t)
+	firstUserAdmin = true
+	defer func() { firstUserAdmin = false }()
+	r := setupRouter()
+
+	first := createTestUser(t, r, "first", "secret123")
+	if first.Role != roleAdmin {
+		t.Fatalf("first user role = %q, want %q", first.Role, roleAdmin)
+	}
+	if second := createTestUser(t, r, "second", "secret456"); second.Role == roleAdmin {
+		t.Error("second user was made admin")
+	}
+	if w := request(t, r, http.MethodDelete, "/users/"+strconv.Itoa(first.ID), "", "first", "secret123"); w.Code != http.StatusOK {
+		t.Fatalf("deleting the first user: got %d %s", w.Code, w.Body.String())
+	}
+	if w := request(t, r, http.MethodDelete, "/users/"+strconv.Itoa(first.ID+1), "", dummyUser.Username, dummyUser.Password); w.Code != http.StatusOK {
+		t.Fatalf("deleting the second user: got %d %s", w.Code, w.Body.String())
+	}
+	if again := createTestUser(t, r, "again", "secret789"); again.Role == roleAdmin {
+		t.Error("registering into an emptied user store granted admin")
+	}
+}
+
+func TestUserUpdatesAreRestrictedToOwnerAndAdmins(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	jane := createTestUser(t, r, "jane", "secret123")
+	createTestUser(t, r, "bob", "secret456")
+	path := "/users/" + strconv.Itoa(jane.ID)
+	update := func(name string) string {
+		return `{"username":"` + name + `","email":"jane@example.com","password":"secret123"}`
+	}
+
+	tests := []struct {
+		name, method, body string
+		auth               []string
+		want               int
+	}{
+		{"anonymous update", http.MethodPut, update("anon"), nil, http.StatusUnauthorized},
+		{"anonymous delete", http.MethodDelete, "", nil, http.StatusUnauthorized},
+		{"other user update", http.MethodPut, update("bobby"), []string{"bob", "secret456"}, http.StatusForbidden},
+		{"other user delete", http.MethodDelete, "", []string{"bob", "secret456"}, http.StatusForbidden},
+		{"own update", http.MethodPut, update("janet"), []string{"jane", "secret123"}, http.StatusOK},
+		{"admin update", http.MethodPut, update("jane"), []string{dummyUser.Username, dummyUser.Password}, http.StatusOK},
+		{"own delete", http.MethodDelete, "", []string{"jane", "secret123"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := request(t, r, tt.method, path, tt.body, tt.auth...); w.Code != tt.want {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorsAreAlwaysLogged(t *testing.T) {
+	var buf bytes.Buffer
+	oldLogger, oldRate, oldThreshold := logger, logSampleRate, slowRequestThreshold
+	logger = slog.New(slog.NewTextHandler(&buf, nil))
+	logSampleRate = 0
+	slowRequestThreshold = 20 * time.Millisecond
+	defer func() { logger, logSampleRate, slowRequestThreshold = oldLogger, oldRate, oldThreshold }()
+
+	r := gin.New()
+	r.Use(LoggerMiddleware())
+	for _, status := range []int{http.StatusOK, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		r.GET("/status/"+strconv.Itoa(status), func(c *gin.Context) { c.Status(status) })
+	}
+	r.GET("/slow", func(c *gin.Context) {
+		time.Sleep(30 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		path string
+		want int // log lines for the request
+	}{
+		{"/status/200", 0},
+		{"/status/400", 1},
+		{"/status/404", 1},
+		{"/status/500", 1},
+		{"/status/503", 1},
+		{"/slow", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			buf.Reset()
+			for i := 0; i < 20; i++ {
+				r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+			}
+			if got := strings.Count(buf.String(), "path="+tt.path); got != 20*tt.want {
+				t.Errorf("logged %d lines for 20 requests, want %d:\n%s", got, 20*tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestRepeatedQueryParametersAreAllApplied(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	admin := []string{dummyUser.Username, dummyUser.Password}
+	jane := createTestUser(t, r, "jane", "secret123")
+	bob := createTestUser(t, r, "bob", "secret456")
+	createTestUser(t, r, "carol", "secret789")
+	postsMu.Lock()
+	for _, p := range []Post{
+		{UserID: jane.ID, Tags: []string{"a", "b"}},
+		{UserID: jane.ID, Tags: []string{"a"}},
+		{UserID: bob.ID, Tags: []string{"a", "b", "c"}},
+	} {
+		p.ID = nextID(&postIDSeq)
+		p.Title, p.Content, p.Status = "t", "c", postStatusPublished
+		posts = append(posts, p)
+	}
+	postsMu.Unlock()
+	auditMu.Lock()
+	for i, action := range []string{"webhook.created", "feature.updated", "webhook.deleted"} {
+		auditLog = append(auditLog, AuditEntry{ID: i + 1, ActorID: dummyUser.ID, Action: action, Resource: "webhook"})
+	}
+	auditMu.Unlock()
+
+	ids := func(path string) []int {
+		t.Helper()
+		w := request(t, r, http.MethodGet, path, "", admin...)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: got %d %s", path, w.Code, w.Body.String())
+		}
+		var items []struct {
+			ID int `json:"id"`
+		}
+		decodeBody(t, w, &items)
+		got := []int{}
+		for _, item := range items {
+			got = append(got, item.ID)
+		}
+		slices.Sort(got)
+		return got
+	}
+	tests := []struct {
+		path string
+		want []int
+	}{
+		{"/posts?tag=a&tag=b", []int{1, 3}},
+		{"/posts?tag=a&tag=b&tag=c", []int{3}},
+		{"/posts?author=jane&author=bob", []int{1, 2, 3}},
+		{"/posts?author=jane&author=carol", []int{1, 2}},
+		{"/posts?author=bob&author=jane&tag=b", []int{1, 3}},
+		{"/admin/activity?action=webhook.created&action=webhook.deleted", []int{1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := ids(tt.path); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, query := range []string{"expand=author", "expand=tags&expand=author", "expand=tags,author"} {
+		w := request(t, r, http.MethodGet, "/posts/1?"+query, "", admin...)
+		if !strings.Contains(w.Body.String(), `"author":{`) {
+			t.Errorf("?%s did not expand the author: %s", query, w.Body.String())
+		}
+	}
+}
+
+func TestUnauthenticatedAndForbiddenAreDistinct(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	createTestUser(t, r, "jane", "secret123")
+	suspended := createTestUser(t, r, "bob", "secret456")
+	if w := request(t, r, http.MethodPost, "/users/"+strconv.Itoa(suspended.ID)+"/suspend", "", dummyUser.Username, dummyUser.Password); w.Code != http.StatusOK {
+		t.Fatalf("suspending: got %d %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name, path string
+		auth       []string
+		status     int
+		code       string
+		message    string
+	}{
+		{"no credentials", "/whoami", nil, http.StatusUnauthorized, "unauthenticated", "authentication required"},
+		{"wrong password", "/whoami", []string{"jane", "wrong"}, http.StatusUnauthorized, "unauthenticated", "authentication required"},
+		{"no credentials on an admin route", "/admin/activity", nil, http.StatusUnauthorized, "unauthenticated", "authentication required"},
+		{"user on an admin route", "/admin/activity", []string{"jane", "secret123"}, http.StatusForbidden, "insufficient_role", "insufficient role"},
+		{"suspended account", "/whoami", []string{"bob", "secret456"}, http.StatusForbidden, "account_suspended", "account suspended"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(t, r, http.MethodGet, tt.path, "", tt.auth...)
+			if w.Code != tt.status {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body.String(), tt.status)
+			}
+			var body struct{ Status, Code, Message string }
+			decodeBody(t, w, &body)
+			if body.Code != tt.code || body.Message != tt.message || body.Status == "" {
+				t.Errorf("body = %+v, want code %q and message %q", body, tt.code, tt.message)
+			}
+			if hasChallenge := w.Header().Get("WWW-Authenticate") != ""; hasChallenge != (tt.status == http.StatusUnauthorized) {
+				t.Errorf("WWW-Authenticate = %q on a %d", w.Header().Get("WWW-Authenticate"), w.Code)
+			}
+		})
+	}
+
+	if w := request(t, r, http.MethodGet, "/admin/activity", "", dummyUser.Username, dummyUser.Password); w.Code != http.StatusOK {
+		t.Errorf("admin on an admin route: got %d", w.Code)
+	}
+}
+
+func TestUserResponsesNeverIncludePasswords(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	admin := []string{dummyUser.Username, dummyUser.Password}
+	w := request(t, r, http.MethodPost, "/users", `{"username":"jane","email":"jane@example.com","password":"topsecret1"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: got %d %s", w.Code, w.Body.String())
+	}
+	var jane User
+	decodeBody(t, w, &jane)
+	path := "/users/" + strconv.Itoa(jane.ID)
+	responses := map[string]*httptest.ResponseRecorder{
+		"create": w,
+		"update": request(t, r, http.MethodPut, path, `{"username":"jane","email":"jane@example.com","password":"topsecret2"}`, "jane", "topsecret1"),
+		"role":   request(t, r, http.MethodPut, path+"/role", `{"role":"admin"}`, admin...),
+	}
+	responses["suspend"] = request(t, r, http.MethodPost, path+"/suspend", "", admin...)
+	responses["unsuspend"] = request(t, r, http.MethodPost, path+"/unsuspend", "", admin...)
+	for name, w := range responses {
+		if w.Code >= 300 {
+			t.Errorf("%s: got %d %s", name, w.Code, w.Body.String())
+		}
+		if body := w.Body.String(); strings.Contains(body, "password") || strings.Contains(body, "topsecret") {
+			t.Errorf("%s response leaks the password: %s", name, body)
+		}
+	}
+}
+
+func TestUserETagsAllowConditionalUpdates(t *testing.T) {
+	resetStores(t)
+	old := requireIfMatch
+	requireIfMatch = true
+	defer func() { requireIfMatch = old }()
+	r := setupRouter()
+	admin := []string{dummyUser.Username, dummyUser.Password}
+	jane := createTestUser(t, r, "jane", "secret123")
+	path := "/users/" + strconv.Itoa(jane.ID)
+
+	etag := request(t, r, http.MethodGet, path, "", admin...).Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("GET /users/:id sent no ETag")
+	}
+	if anon := request(t, r, http.MethodGet, path, "").Header().Get("ETag"); anon != etag {
+		t.Errorf("ETag depends on the caller: %q for admins, %q anonymously", etag, anon)
+	}
+
+	put := func(ifMatch, password string) *httptest.ResponseRecorder {
+		body := `{"username":"jane","email":"jane@example.com","password":"` + password + `"}`
+		req := httptest.NewRequest(http.MethodPut, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.SetBasicAuth(admin[0], admin[1])
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := put("", "secret456"); w.Code != http.StatusPreconditionRequired {
+		t.Errorf("without If-Match: got %d, want 428", w.Code)
+	}
+	if w := put(`"stale"`, "secret456"); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("with a stale If-Match: got %d, want 412", w.Code)
+	}
+	w := put(etag, "secret456")
+	if w.Code != http.StatusOK {
+		t.Fatalf("with the profile's ETag: got %d %s, want 200", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("ETag"); got != etag {
+		t.Errorf("a password change moved the ETag from %q to %q", etag, got)
+	}
+}
+
+func TestEmailDomainsCountEveryUser(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	createTestUser(t, r, "alice", "pw")
+	w := request(t, r, http.MethodPost, "/users", `{"username":"bob","email":"Bob@Mail.Example.org","password":"pw"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create bob: %d %s", w.Code, w.Body.String())
+	}
+
+	w = request(t, r, http.MethodGet, "/admin/users/email-domains", "", dummyUser.Username, dummyUser.Password)
+	if w.Code != http.StatusOK {
+		t.Fatalf("email domains: %d %s", w.Code, w.Body.String())
+	}
+	var counts map[string]int
+	decodeBody(t, w, &counts)
+	// alice and the built-in admin share example.com
+	want := map[string]int{"example.com": 2, "mail.example.org": 1}
+	if len(counts) != len(want) || counts["example.com"] != 2 || counts["mail.example.org"] != 1 {
+		t.Errorf("got %v, want %v", counts, want)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// Middleware that validates the raw request body against schemas/<name>.json.
+// Validation is skipped when SCHEMA_VALIDATION is off or the schema file doesn't exist.
+func SchemaMiddleware(name string) gin.HandlerFunc {
+	path := filepath.Join(schemaDir, name+".json")
+	if !schemaValidation {
+		return func(c *gin.Context) { c.Next() }
+	}
+	if _, err := os.Stat(path); err != nil {
+		logger.Warn("no schema, skipping validation", "schema", name)
+		return func(c *gin.Context) { c.Next() }
+	}
+	schema, err := jsonschema.NewCompiler().Compile(path)
+	if err != nil {
+		log.Fatalf("invalid schema %s: %v", path, err)
+	}
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Set(gin.BodyBytesKey, body)
+		if isEmptyBody(body) {
+			recordBindFailure(c, errEmptyBody)
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": errEmptyBody.Error()})
+			return
+		}
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+		if err != nil {
+			recordBindFailure(c, err)
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON body"})
+			return
+		}
+		if err := schema.Validate(doc); err != nil {
+			recordBindFailure(c, err)
+			var validationErr *jsonschema.ValidationError
+			if !errors.As(err, &validationErr) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+				"error":  "Schema validation failed",
+				"fields": schemaFieldErrors(validationErr.BasicOutput()),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Flatten schema validation output into field errors
+func schemaFieldErrors(output *jsonschema.OutputUnit) []FieldError {
+	fields := []FieldError{}
+	for _, unit := range output.Errors {
+		if unit.Error == nil {
+			continue
+		}
+		keyword := unit.KeywordLocation[strings.LastIndex(unit.KeywordLocation, "/")+1:]
+		fields = append(fields, FieldError{
+			Field:   strings.ReplaceAll(strings.TrimPrefix(unit.InstanceLocation, "/"), "/", "."),
+			Rule:    keyword,
+			Message: unit.Error.String(),
+		})
+	}
+	return fields
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchResult is one match of a global search; Type tells users and posts apart
+type SearchResult struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// UserSummary is the view of a user non-admins get in search results, without the email
+type UserSummary struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+// Search users and posts at once. ?type=user or ?type=post limits the search
+// to one kind; users come first otherwise.
+func search(c *gin.Context) {
+	var query struct {
+		Pagination
+		Q    string `form:"q"`
+		Type string `form:"type" binding:"omitempty,oneof=user post"`
+	}
+	if !bindQuery(c, &query) {
+		return
+	}
+	q := strings.TrimSpace(query.Q)
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+		return
+	}
+	results := []SearchResult{}
+	if query.Type == "" || query.Type == "user" {
+		results = append(results, searchUsers(c, q)...)
+	}
+	if query.Type == "" || query.Type == "post" {
+		results = append(results, searchPosts(c, q)...)
+	}
+	start, end := paginate(c, len(results), query.Page, query.Limit)
+	c.JSON(http.StatusOK, results[start:end])
+}
+
+// Helper function to find users whose username contains q, ignoring case.
+// Admins also match on email and see the full public user.
+func searchUsers(c *gin.Context, q string) []SearchResult {
+	q = strings.ToLower(q)
+	admin := isAdmin(c)
+	usersMu.RLock()
+	candidates := append([]User{dummyUser}, users...)
+	usersMu.RUnlock()
+	results := []SearchResult{}
+	for _, user := range candidates {
+		match := strings.Contains(strings.ToLower(user.Username), q)
+		if admin {
+			match = match || strings.Contains(strings.ToLower(user.Email), q)
+		}
+		if !match {
+			continue
+		}
+		if admin {
+			results = append(results, SearchResult{Type: "user", Data: toPublicUser(c, user)})
+		} else {
+			results = append(results, SearchResult{Type: "user", Data: UserSummary{ID: user.ID, Username: user.Username}})
+		}
+	}
+	return results
+}
+
+// Helper function to find readable posts whose title or content contains q, ignoring case
+func searchPosts(c *gin.Context, q string) []SearchResult {
+	q = strings.ToLower(q)
+	results := []SearchResult{}
+	postsMu.RLock()
+	defer postsMu.RUnlock()
+	for _, post := range posts {
+		if post.DeletedAt != nil || !canReadPost(c, post) {
+			continue
+		}
+		if strings.Contains(strings.ToLower(post.Title), q) || strings.Contains(strings.ToLower(post.Content), q) {
+			item := PostListItem{Post: post, Excerpt: excerpt(post.Content, postExcerptLength)}
+			results = append(results, SearchResult{Type: "post", Data: item})
+		}
+	}
+	return results
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"cmp"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SimilarPost is a post listed as similar to another, with its similarity score
+type SimilarPost struct {
+	PostListItem
+	Score float64 `json:"score"`
+}
+
+// Common English words that carry no meaning for similarity
+var stopWords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`a about after all also an and any are as at be because
+		been but by can could did do does for from had has have he her his how i if in into is
+		it its just like me more most my no not of on or our out over so some than that the
+		their them then there these they this to up us was we were what when which who will
+		with would you your`) {
+		stopWords[word] = true
+	}
+}
+
+// Term frequencies of a post's content, with their vector length
+type termVector struct {
+	content string
+	terms   map[string]float64
+	norm    float64
+}
+
+// Tokenized content per post ID, rebuilt when the content changes
+var (
+	termVectors   = map[int]termVector{}
+	termVectorsMu sync.Mutex
+)
+
+// Split content into lower-cased words, leaving out stop words and single letters
+func tokenize(content string) []string {
+	words := strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := words[:0]
+	for _, word := range words {
+		if len([]rune(word)) > 1 && !stopWords[word] {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// Get the term vector of a post, tokenizing its content only when it isn't
+// cached yet or has changed since
+func postTermVector(post Post) termVector {
+	termVectorsMu.Lock()
+	defer termVectorsMu.Unlock()
+	if vec, ok := termVectors[post.ID]; ok && vec.content == post.Content {
+		return vec
+	}
+	vec := termVector{content: post.Content, terms: map[string]float64{}}
+	for _, token := range tokenize(post.Content) {
+		vec.terms[token]++
+	}
+	for _, tf := range vec.terms {
+		vec.norm += tf * tf
+	}
+	vec.norm = math.Sqrt(vec.norm)
+	termVectors[post.ID] = vec
+	return vec
+}
+
+// Cosine similarity of two term vectors, 0 when either is empty
+func cosineSimilarity(a, b termVector) float64 {
+	if a.norm == 0 || b.norm == 0 {
+		return 0
+	}
+	if len(a.terms) > len(b.terms) {
+		a, b = b, a
+	}
+	dot := 0.0
+	for term, tf := range a.terms {
+		dot += tf * b.terms[term]
+	}
+	return dot / (a.norm * b.norm)
+}
+
+// Get the published posts whose content is most similar to a post's, by
+// term-frequency cosine similarity. Posts scoring below similarThreshold are
+// left out, so the list may be empty; ?limit= caps its length.
+func getSimilarPosts(c *gin.Context) {
+	var query struct {
+		Limit int `form:"limit" binding:"omitempty,min=1"`
+	}
+	if !bindQuery(c, &query) {
+		return
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = similarPostsLimit
+	}
+	limit = min(limit, maxLimit)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
+		return
+	}
+	postsMu.RLock()
+	defer postsMu.RUnlock()
+	post := findPostByID(id, false)
+	if post == nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
+		return
+	}
+	if !canReadPost(c, *post) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed to read this post"})
+		return
+	}
+	target := postTermVector(*post)
+	similar := []SimilarPost{}
+	for _, other := range posts {
+		// Scoring every post is the slow part; stop once the request times out
+		if c.Request.Context().Err() != nil {
+			return
+		}
+		if other.ID == post.ID || other.Status != postStatusPublished || other.DeletedAt != nil {
+			continue
+		}
+		score := cosineSimilarity(target, postTermVector(other))
+		if score < similarThreshold || score == 0 {
+			continue
+		}
+		similar = append(similar, SimilarPost{
+			PostListItem: PostListItem{Post: other, Excerpt: excerpt(other.Content, postExcerptLength)},
+			Score:        score,
+		})
+	}
+	slices.SortFunc(similar, func(a, b SimilarPost) int {
+		if byScore := cmp.Compare(b.Score, a.Score); byScore != 0 {
+			return byScore
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+	c.JSON(http.StatusOK, similar)
+}
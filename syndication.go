@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Link    atomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Link    atomLink `xml:"link"`
+	Updated string   `xml:"updated"`
+	Summary string   `xml:"summary"`
+}
+
+// Helper function to get the latest published posts for the feeds
+func latestPublishedPosts(n int) []Post {
+	latest := []Post{}
+	postsMu.RLock()
+	for _, post := range posts {
+		if post.Status == postStatusPublished && post.DeletedAt == nil {
+			latest = append(latest, post)
+		}
+	}
+	postsMu.RUnlock()
+	sort.SliceStable(latest, func(i, j int) bool {
+		return newerPost(latest[i], latest[j])
+	})
+	if len(latest) > n {
+		latest = latest[:n]
+	}
+	return latest
+}
+
+// Helper function to cut content down to a short plain excerpt
+func feedExcerpt(content string) string {
+	return excerpt(content, feedExcerptLength)
+}
+
+// Helper function to cut content down to at most n characters, backing up to
+// the last word boundary so no word (or multibyte character) is split
+func excerpt(content string, n int) string {
+	runes := []rune(content)
+	if len(runes) <= n {
+		return content
+	}
+	cut := runes[:n]
+	if !unicode.IsSpace(runes[n]) {
+		for i := len(cut) - 1; i > 0; i-- {
+			if unicode.IsSpace(cut[i]) {
+				cut = cut[:i]
+				break
+			}
+		}
+	}
+	return strings.TrimRightFunc(string(cut), unicode.IsSpace) + "..."
+}
+
+// Helper function to build the public URL of a post
+func postURL(post Post) string {
+	return fmt.Sprintf("%s/posts/%d", siteURL, post.ID)
+}
+
+// Latest published posts as an RSS 2.0 feed
+func getRSSFeed(c *gin.Context) {
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{Title: siteTitle, Link: siteURL, Description: siteTitle + " posts"},
+	}
+	for _, post := range latestPublishedPosts(feedItemCount) {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       post.Title,
+			Link:        postURL(post),
+			GUID:        postURL(post),
+			Description: feedExcerpt(post.Content),
+			PubDate:     post.Created.UTC().Format(time.RFC1123Z),
+		})
+	}
+	writeXML(c, "application/rss+xml", feed)
+}
+
+// Latest published posts as an Atom feed
+func getAtomFeed(c *gin.Context) {
+	latest := latestPublishedPosts(feedItemCount)
+	updated := startTime
+	if len(latest) > 0 {
+		updated = latest[0].Created
+	}
+	feed := atomFeed{
+		Title:   siteTitle,
+		ID:      siteURL + "/",
+		Link:    atomLink{Href: siteURL},
+		Updated: updated.UTC().Format(time.RFC3339),
+	}
+	for _, post := range latest {
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:   post.Title,
+			ID:      postURL(post),
+			Link:    atomLink{Href: postURL(post)},
+			Updated: post.Created.UTC().Format(time.RFC3339),
+			Summary: feedExcerpt(post.Content),
+		})
+	}
+	writeXML(c, "application/atom+xml", feed)
+}
+
+// Helper function to write an XML document with the given content type
+func writeXML(c *gin.Context, contentType string, doc interface{}) {
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, contentType+"; charset=utf-8", append([]byte(xml.Header), data...))
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Helper function to check a post's tags against the configured limits,
+// returning why they're invalid or "" when they're fine
+func tagsError(tags []string) string {
+	if len(tags) > maxTagsPerPost {
+		return fmt.Sprintf("at most %d tags are allowed", maxTagsPerPost)
+	}
+	for _, tag := range tags {
+		if strings.TrimSpace(tag) == "" {
+			return "tags must not be empty"
+		}
+		if strings.ContainsFunc(tag, unicode.IsControl) {
+			return fmt.Sprintf("tag %q contains control characters", tag)
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return fmt.Sprintf("tags must be at most %d characters", maxTagLength)
+		}
+	}
+	return ""
+}
+
+// Helper function to check whether a post carries every one of the given
+// tags, ignoring case
+func hasAllTags(post Post, tags []string) bool {
+	for _, want := range tags {
+		if !slices.ContainsFunc(post.Tags, func(tag string) bool { return strings.EqualFold(tag, want) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// Reject invalid post tags with a 400
+func checkTags(c *gin.Context, tags []string) bool {
+	if msg := tagsError(tags); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tags: " + msg})
+		return false
+	}
+	return true
+}
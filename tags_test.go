@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTagLimits(t *testing.T) {
+	tags := func(n int) []string {
+		out := make([]string, n)
+		for i := range out {
+			out[i] = "tag" + strings.Repeat("x", i)
+		}
+		return out
+	}
+	tests := []struct {
+		name  string
+		tags  []string
+		valid bool
+	}{
+		{"no tags", nil, true},
+		{"exactly the maximum count", tags(maxTagsPerPost), true},
+		{"one over the maximum count", tags(maxTagsPerPost + 1), false},
+		{"exactly the maximum length", []string{strings.Repeat("a", maxTagLength)}, true},
+		{"one over the maximum length", []string{strings.Repeat("a", maxTagLength+1)}, false},
+		{"length counts runes, not bytes", []string{strings.Repeat("é", maxTagLength)}, true},
+		{"empty tag", []string{""}, false},
+		{"whitespace-only tag", []string{" \t "}, false},
+		{"control character", []string{"go\x00lang"}, false},
+		{"inner space is allowed", []string{"web dev"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if msg := tagsError(tt.tags); (msg == "") != tt.valid {
+				t.Errorf("tagsError(%q) = %q, want valid=%v", tt.tags, msg, tt.valid)
+			}
+		})
+	}
+}
+
+func TestTooManyTagsAreRejected(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	admin := []string{dummyUser.Username, dummyUser.Password}
+	tags := make([]string, maxTagsPerPost+1)
+	for i := range tags {
+		tags[i] = "t" + strings.Repeat("x", i)
+	}
+	body, _ := json.Marshal(gin.H{"title": "t", "content": "c", "tags": tags})
+
+	if w := request(t, r, http.MethodPost, "/posts", string(body), admin...); w.Code != http.StatusBadRequest {
+		t.Errorf("create: got %d, want 400", w.Code)
+	}
+	post := createTestPost(t, r, `{"title":"t","content":"c"}`, dummyUser.Username, dummyUser.Password)
+	if w := request(t, r, http.MethodPut, "/posts/"+strconv.Itoa(post.ID), string(body), admin...); w.Code != http.StatusBadRequest {
+		t.Errorf("update: got %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Characters a username may be made of
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Usernames that would be confused with routes or the built-in account
+var reservedUsernames = []string{"admin", "me"}
+
+// Helper function to check a username against the configured rules,
+// returning why it's invalid or "" when it's fine
+func usernameError(username string) string {
+	if n := utf8.RuneCountInString(username); n < minUsernameLength || n > maxUsernameLength {
+		return fmt.Sprintf("must be between %d and %d characters", minUsernameLength, maxUsernameLength)
+	}
+	if !usernamePattern.MatchString(username) {
+		return "may only contain letters, digits, underscores and hyphens"
+	}
+	if slices.Contains(reservedUsernames, strings.ToLower(username)) {
+		return fmt.Sprintf("%q is reserved", username)
+	}
+	return ""
+}
+
+// Reject an invalid username with a 400
+func checkUsername(c *gin.Context, username string) bool {
+	if msg := usernameError(username); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid username: " + msg})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUsernameRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		reason   string // substring of the error, "" when valid
+	}{
+		{"shortest allowed", strings.Repeat("a", minUsernameLength), ""},
+		{"longest allowed", strings.Repeat("a", maxUsernameLength), ""},
+		{"too short", strings.Repeat("a", minUsernameLength-1), "between"},
+		{"too long", strings.Repeat("a", maxUsernameLength+1), "between"},
+		{"underscore and hyphen", "jane_doe-1", ""},
+		{"space", "jane doe", "may only contain"},
+		{"symbol", "jane!", "may only contain"},
+		{"non-ASCII letter", "jané", "may only contain"},
+		{"reserved", "admin", "reserved"},
+		{"reserved in another case", "ADMIN", "reserved"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := usernameError(tt.username)
+			if tt.reason == "" && msg != "" {
+				t.Errorf("usernameError(%q) = %q, want valid", tt.username, msg)
+			}
+			if tt.reason != "" && !strings.Contains(msg, tt.reason) {
+				t.Errorf("usernameError(%q) = %q, want it to mention %q", tt.username, msg, tt.reason)
+			}
+		})
+	}
+
+	// "me" is shorter than the default minimum; check it is reserved on its own merits
+	old := minUsernameLength
+	minUsernameLength = 1
+	defer func() { minUsernameLength = old }()
+	if msg := usernameError("me"); !strings.Contains(msg, "reserved") {
+		t.Errorf(`usernameError("me") = %q, want it reserved`, msg)
+	}
+}
+
+func TestReservedUsernamesAreRejected(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	user := createTestUser(t, r, "jane", "secret123")
+	for _, name := range []string{"admin", "Admin", "me"} {
+		body := `{"username":"` + name + `","email":"x@example.com","password":"secret123"}`
+		if w := request(t, r, http.MethodPost, "/users", body); w.Code != http.StatusBadRequest {
+			t.Errorf("create %q: got %d, want 400", name, w.Code)
+		}
+		path := "/users/" + strconv.Itoa(user.ID)
+		if w := request(t, r, http.MethodPut, path, body, "jane", "secret123"); w.Code != http.StatusBadRequest {
+			t.Errorf("rename to %q: got %d, want 400", name, w.Code)
+		}
+	}
+}
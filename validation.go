@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes one invalid field in a request body
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func init() {
+	binding.EnableDecoderUseNumber = jsonUseNumber
+	// Report validation errors using JSON (or query) field names instead of Go ones
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+			if name == "" {
+				name = strings.SplitN(f.Tag.Get("form"), ",", 2)[0]
+			}
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// Returned by bindJSON for a missing, blank or null body
+var errEmptyBody = errors.New("request body is required")
+
+// Check whether a raw body carries no JSON value worth binding
+func isEmptyBody(body []byte) bool {
+	body = bytes.TrimSpace(body)
+	return len(body) == 0 || string(body) == "null"
+}
+
+// Bind the JSON request body into obj, normalize its tagged string fields
+// and validate it. When strictJSON is enabled, fields that don't exist on obj
+// are rejected instead of ignored.
+func bindJSON(c *gin.Context, obj interface{}) error {
+	var body []byte
+	if cached, ok := c.Get(gin.BodyBytesKey); ok {
+		body, _ = cached.([]byte)
+	} else {
+		var err error
+		if body, err = io.ReadAll(c.Request.Body); err != nil {
+			return err
+		}
+		// Keep the raw body around so binding failures can be logged
+		c.Set(gin.BodyBytesKey, body)
+	}
+	if isEmptyBody(body) {
+		return errEmptyBody
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if strictJSON {
+		dec.DisallowUnknownFields()
+	}
+	if jsonUseNumber {
+		dec.UseNumber()
+	}
+	if err := dec.Decode(obj); err != nil {
+		return err
+	}
+	normalizeStrings(reflect.ValueOf(obj))
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// Apply the normalize tag of every string (or *string) field in a bound
+// struct, including embedded ones: "trim" strips leading and trailing
+// whitespace, "collapse" also squeezes inner runs of whitespace into one space
+func normalizeStrings(v reflect.Value) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field, value := v.Type().Field(i), v.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous {
+			normalizeStrings(value.Addr())
+			continue
+		}
+		mode := field.Tag.Get("normalize")
+		if mode == "" {
+			continue
+		}
+		if value.Kind() == reflect.Pointer && !value.IsNil() {
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.String {
+			value.SetString(normalizeString(value.String(), mode))
+		}
+	}
+}
+
+// Normalize one string the way a normalize tag asks for
+func normalizeString(s, mode string) string {
+	switch mode {
+	case "trim":
+		return strings.TrimSpace(s)
+	case "collapse":
+		return strings.Join(strings.Fields(s), " ")
+	default:
+		return s
+	}
+}
+
+// Error handler for JSON binding errors
+func handleBindError(c *gin.Context, err error) {
+	recordBindFailure(c, err)
+	var typeErr *json.UnmarshalTypeError
+	var validationErrs validator.ValidationErrors
+	switch {
+	case errors.Is(err, errEmptyBody):
+		c.JSON(http.StatusBadRequest, gin.H{"error": errEmptyBody.Error()})
+	case errors.As(err, &typeErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "Validation failed",
+			"fields": []FieldError{{
+				Field:   typeErr.Field,
+				Rule:    "type",
+				Message: "expected " + jsonTypeName(typeErr.Type),
+			}},
+		})
+	case errors.As(err, &validationErrs):
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldError{
+				Field:   fieldPath(fe.Namespace()),
+				Rule:    fe.Tag(),
+				Message: validationMessage(fe),
+			})
+		}
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Validation failed", "fields": fields})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown field " + field})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// Strip the top-level struct name from a validator namespace
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// Describe a Go type the way a JSON client would understand it
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// Human readable message for a failed validation rule
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of: " + fe.Param()
+	default:
+		return "failed " + fe.Tag() + " validation"
+	}
+}
+
+// Count of binding and validation failures per endpoint
+var (
+	bindFailures   = map[string]int{}
+	bindFailuresMu sync.Mutex
+)
+
+// Count a binding failure for the current endpoint and log it with the redacted body
+func recordBindFailure(c *gin.Context, err error) {
+	endpoint := c.Request.Method + " " + c.FullPath()
+	bindFailuresMu.Lock()
+	bindFailures[endpoint]++
+	bindFailuresMu.Unlock()
+	var body []byte
+	if b, ok := c.Get(gin.BodyBytesKey); ok {
+		body, _ = b.([]byte)
+	}
+	logger.Info("binding failure", "endpoint", endpoint, "request_id", c.GetString("request_id"),
+		"error", err, "body", formatLoggedBody(body))
+}
+
+// Get the binding failure counts per endpoint
+func getBindFailures(c *gin.Context) {
+	bindFailuresMu.Lock()
+	counts := make(map[string]int, len(bindFailures))
+	for endpoint, count := range bindFailures {
+		counts[endpoint] = count
+	}
+	bindFailuresMu.Unlock()
+	c.JSON(http.StatusOK, counts)
+}
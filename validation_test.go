@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLargeIntegerIDsRoundTripExactly(t *testing.T) {
+	const id = "9007199254740993" // 2^53 + 1, not representable as float64
+	body := `{"id":` + id + `}`
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	var data map[string]interface{}
+	if err := bindJSON(c, &data); err != nil {
+		t.Fatalf("bindJSON: %v", err)
+	}
+	n, ok := data["id"].(json.Number)
+	if !ok || n.String() != id {
+		t.Fatalf("decoded id = %#v, want json.Number %s", data["id"], id)
+	}
+	out, _ := json.Marshal(data)
+	if string(out) != body {
+		t.Errorf("re-encoded body = %s, want %s", out, body)
+	}
+
+	if logged := formatLoggedBody([]byte(body)); !strings.Contains(logged, id) {
+		t.Errorf("logged body %s lost digits of %s", logged, id)
+	}
+}
+
+func TestBindingNormalizesStrings(t *testing.T) {
+	title := "  Hello \t  world\n "
+	tests := []struct {
+		name string
+		obj  interface{}
+		want interface{}
+	}{
+		{"username and email are trimmed",
+			&User{Username: " admin ", Email: " admin@example.com\t", Password: " keep spaces "},
+			&User{Username: "admin", Email: "admin@example.com", Password: " keep spaces "}},
+		{"title runs are collapsed, content is left alone",
+			&Post{Title: title, Content: "  as  written  "},
+			&Post{Title: "Hello world", Content: "  as  written  "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalizeStrings(reflect.ValueOf(tt.obj))
+			if !reflect.DeepEqual(tt.obj, tt.want) {
+				t.Errorf("got %+v, want %+v", tt.obj, tt.want)
+			}
+		})
+	}
+
+	patch := struct {
+		Title *string `normalize:"collapse"`
+	}{&title}
+	normalizeStrings(reflect.ValueOf(&patch))
+	if *patch.Title != "Hello world" {
+		t.Errorf("pointer field = %q, want %q", *patch.Title, "Hello world")
+	}
+}
+
+func TestPaddedUsernamesAreNormalized(t *testing.T) {
+	resetStores(t)
+	r := setupRouter()
+	if w := request(t, r, http.MethodPost, "/users", `{"username":" admin ","email":"x@example.com","password":"secret123"}`); w.Code != http.StatusBadRequest {
+		t.Errorf(`" admin " was not normalized to the reserved "admin": got %d`, w.Code)
+	}
+	w := request(t, r, http.MethodPost, "/users", `{"username":"  jane ","email":" jane@example.com ","password":"secret123"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: got %d %s", w.Code, w.Body.String())
+	}
+	var created User
+	decodeBody(t, w, &created)
+	user, _ := findUserByID(created.ID)
+	if user.Username != "jane" || user.Email != "jane@example.com" {
+		t.Errorf("stored %q / %q, want trimmed values", user.Username, user.Email)
+	}
+	if w := request(t, r, http.MethodGet, "/whoami", "", "jane", "secret123"); w.Code != http.StatusOK {
+		t.Errorf("login with the trimmed username: got %d", w.Code)
+	}
+	if w := request(t, r, http.MethodPost, "/users", `{"username":"jane ","email":"j2@example.com","password":"secret123"}`); w.Code != http.StatusConflict {
+		t.Errorf("padded duplicate: got %d, want 409", w.Code)
+	}
+}
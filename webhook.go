@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Webhook is an admin-registered URL notified about post events
+type Webhook struct {
+	ID      int       `json:"id"`
+	URL     string    `json:"url" binding:"required,url"`
+	Secret  string    `json:"secret"`
+	Created time.Time `json:"created"`
+}
+
+// Events delivered to webhooks
+const (
+	eventPostCreated   = "post.created"
+	eventPostPublished = "post.published"
+)
+
+var (
+	webhooks     = []Webhook{}
+	webhooksMu   sync.RWMutex
+	webhookIDSeq atomic.Int64
+)
+
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// Get all registered webhooks
+func getWebhooks(c *gin.Context) {
+	webhooksMu.RLock()
+	result := make([]Webhook, len(webhooks))
+	copy(result, webhooks)
+	webhooksMu.RUnlock()
+	c.JSON(http.StatusOK, result)
+}
+
+// Register a new webhook; a signing secret is generated when none is given
+func createWebhook(c *gin.Context) {
+	var newWebhook Webhook
+	if err := bindJSON(c, &newWebhook); err != nil {
+		handleBindError(c, err)
+		return
+	}
+	if newWebhook.Secret == "" {
+		newWebhook.Secret = randomHex(32)
+	}
+	newWebhook.ID = nextID(&webhookIDSeq)
+	newWebhook.Created = time.Now()
+	webhooksMu.Lock()
+	webhooks = append(webhooks, newWebhook)
+	webhooksMu.Unlock()
+	recordAudit(c, "webhook.created", "webhook", newWebhook.ID, newWebhook.URL)
+	c.Header("Location", fmt.Sprintf("/admin/webhooks/%v", newWebhook.ID))
+	c.JSON(http.StatusCreated, newWebhook)
+}
+
+// Remove a registered webhook
+func deleteWebhook(c *gin.Context) {
+	id := c.Param("id")
+	webhooksMu.Lock()
+	defer webhooksMu.Unlock()
+	for i, webhook := range webhooks {
+		if strconv.Itoa(webhook.ID) == id {
+			webhooks = append(webhooks[:i], webhooks[i+1:]...)
+			recordAudit(c, "webhook.deleted", "webhook", webhook.ID, webhook.URL)
+			c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"message": "Webhook not found"})
+}
+
+// Notify every registered webhook about an event without blocking the caller
+func dispatchEvent(event string, data interface{}) {
+	payload, err := json.Marshal(gin.H{"event": event, "data": data, "sent_at": time.Now().UTC()})
+	if err != nil {
+		logger.Error("encoding webhook payload failed", "event", event, "error", err)
+		return
+	}
+	webhooksMu.RLock()
+	targets := make([]Webhook, len(webhooks))
+	copy(targets, webhooks)
+	webhooksMu.RUnlock()
+	for _, webhook := range targets {
+		go deliverWebhook(webhook, event, payload)
+	}
+}
+
+// POST a signed payload to a webhook, retrying with backoff on failure
+func deliverWebhook(webhook Webhook, event string, payload []byte) {
+	signature := signPayload(webhook.Secret, payload)
+	backoff := time.Second
+	for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+		err := postWebhook(webhook.URL, event, signature, payload)
+		if err == nil {
+			return
+		}
+		logger.Error("webhook delivery failed", "webhook_id", webhook.ID, "event", event,
+			"attempt", attempt, "max_attempts", webhookMaxAttempts, "error", err)
+		if attempt < webhookMaxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
+
+// Sign a payload with HMAC-SHA256, formatted as "sha256=<hex>"
+func signPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Middleware for routes that receive webhooks from other services. It checks
+// the HMAC-SHA256 signature in the named header against the raw body, in the
+// same "sha256=<hex>" form this service signs its own deliveries with, and
+// answers 401 when it doesn't match. The body is restored for the handler.
+func VerifyWebhookSignature(secret, header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Set(gin.BodyBytesKey, body)
+		expected := signPayload(secret, body)
+		if !hmac.Equal([]byte(c.GetHeader(header)), []byte(expected)) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid webhook signature"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Helper function to send one webhook request
+func postWebhook(url, event, signature string, payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event", event)
+	req.Header.Set("X-Signature", signature)
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
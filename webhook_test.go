@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	const secret = "shh"
+	const payload = `{"event":"payment.succeeded","amount":100}`
+	r := gin.New()
+	r.POST("/hook", VerifyWebhookSignature(secret, "X-Signature"), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, "%s", body)
+	})
+
+	tests := []struct {
+		name      string
+		body      string
+		signature string
+		want      int
+	}{
+		{"valid signature", payload, signPayload(secret, []byte(payload)), http.StatusOK},
+		{"tampered payload", strings.Replace(payload, "100", "1000", 1), signPayload(secret, []byte(payload)), http.StatusUnauthorized},
+		{"signed with another secret", payload, signPayload("other", []byte(payload)), http.StatusUnauthorized},
+		{"signature without prefix", payload, strings.TrimPrefix(signPayload(secret, []byte(payload)), "sha256="), http.StatusUnauthorized},
+		{"missing signature", payload, "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(tt.body))
+			if tt.signature != "" {
+				req.Header.Set("X-Signature", tt.signature)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body.String(), tt.want)
+			}
+			if tt.want == http.StatusOK && w.Body.String() != tt.body {
+				t.Errorf("handler read body %q, want %q", w.Body.String(), tt.body)
+			}
+		})
+	}
+}